@@ -0,0 +1,177 @@
+package collide
+
+import (
+	"math"
+)
+
+// AABB represents an axis-aligned bounding box.
+type AABB struct {
+	Min, Max Point
+}
+
+// ComputeAABB returns the bounding box of a shape in world space.
+func ComputeAABB(s Shape, xf Transform) AABB {
+	switch s := s.(type) {
+	case *Circle:
+		p := xf.Mul(s.Center)
+		r := Point{s.Radius, s.Radius}
+		return AABB{p.Sub(r), p.Add(r)}
+	case *Polygon:
+		p := xf.Mul(s.Points[0])
+		aabb := AABB{p, p}
+		for i := 1; i < len(s.Points); i++ {
+			aabb = aabb.addPoint(xf.Mul(s.Points[i]))
+		}
+		return aabb
+	case *Box:
+		// Project the rotated extents onto the axes
+		p := xf.Mul(s.Center)
+		cos, sin := math.Abs(xf.Rotation.Cos), math.Abs(xf.Rotation.Sin)
+		e := Point{
+			cos*s.Extents.X + sin*s.Extents.Y,
+			sin*s.Extents.X + cos*s.Extents.Y,
+		}
+		return AABB{p.Sub(e), p.Add(e)}
+	}
+	return computeAABBConvex(s, xf)
+}
+
+// computeAABBConvex returns the bounding box of an arbitrary convex shape
+// from its support points along the axes.
+func computeAABBConvex(s Shape, xf Transform) AABB {
+	support := func(dir Point) Point {
+		return xf.Mul(s.GetVertex(s.GetSupport(xf.Rotation.MulT(dir))))
+	}
+	r := s.GetRadius()
+	return AABB{
+		Min: Point{support(Point{-1, 0}).X - r, support(Point{0, -1}).Y - r},
+		Max: Point{support(Point{1, 0}).X + r, support(Point{0, 1}).Y + r},
+	}
+}
+
+// IsValid reports whether the bounding box is not inverted and has no NaN
+// coordinates.
+func (a AABB) IsValid() bool {
+	return a.Min.X <= a.Max.X && a.Min.Y <= a.Max.Y
+}
+
+// Center returns the center of the bounding box.
+func (a AABB) Center() Point {
+	return Point{(a.Min.X + a.Max.X) / 2, (a.Min.Y + a.Max.Y) / 2}
+}
+
+// Extents returns the half extents of the bounding box.
+func (a AABB) Extents() Point {
+	return Point{(a.Max.X - a.Min.X) / 2, (a.Max.Y - a.Min.Y) / 2}
+}
+
+// Perimeter returns the perimeter of the bounding box.
+func (a AABB) Perimeter() float64 {
+	return 2 * ((a.Max.X - a.Min.X) + (a.Max.Y - a.Min.Y))
+}
+
+// Union returns the smallest bounding box containing a and b.
+func (a AABB) Union(b AABB) AABB {
+	return AABB{
+		Min: Point{math.Min(a.Min.X, b.Min.X), math.Min(a.Min.Y, b.Min.Y)},
+		Max: Point{math.Max(a.Max.X, b.Max.X), math.Max(a.Max.Y, b.Max.Y)},
+	}
+}
+
+// addPoint returns the smallest bounding box containing a and p.
+func (a AABB) addPoint(p Point) AABB {
+	if p.X < a.Min.X {
+		a.Min.X = p.X
+	} else if p.X > a.Max.X {
+		a.Max.X = p.X
+	}
+	if p.Y < a.Min.Y {
+		a.Min.Y = p.Y
+	} else if p.Y > a.Max.Y {
+		a.Max.Y = p.Y
+	}
+	return a
+}
+
+// Expand returns the bounding box grown by d on every side.
+func (a AABB) Expand(d float64) AABB {
+	return AABB{
+		Min: Point{a.Min.X - d, a.Min.Y - d},
+		Max: Point{a.Max.X + d, a.Max.Y + d},
+	}
+}
+
+// Contains reports whether the bounding box contains b.
+func (a AABB) Contains(b AABB) bool {
+	return a.Min.X <= b.Min.X && a.Min.Y <= b.Min.Y &&
+		b.Max.X <= a.Max.X && b.Max.Y <= a.Max.Y
+}
+
+// ContainsPoint reports whether the bounding box contains p.
+func (a AABB) ContainsPoint(p Point) bool {
+	return a.Min.X <= p.X && p.X <= a.Max.X &&
+		a.Min.Y <= p.Y && p.Y <= a.Max.Y
+}
+
+// Overlaps reports whether the bounding boxes a and b overlap.
+// Bounding boxes that touch are considered overlapping.
+func (a AABB) Overlaps(b AABB) bool {
+	return a.Min.X <= b.Max.X && b.Min.X <= a.Max.X &&
+		a.Min.Y <= b.Max.Y && b.Min.Y <= a.Max.Y
+}
+
+// RayCast casts a ray against the bounding box using the slab method.
+// Rays that start inside the bounding box do not hit it.
+func (a AABB) RayCast(input *RayCastInput) RayCastOutput {
+	tmin := math.Inf(-1)
+	tmax := math.Inf(1)
+	var normal Point
+
+	p := [2]float64{input.Origin.X, input.Origin.Y}
+	d := [2]float64{input.Direction.X, input.Direction.Y}
+	lower := [2]float64{a.Min.X, a.Min.Y}
+	upper := [2]float64{a.Max.X, a.Max.Y}
+	for i := 0; i < 2; i++ {
+		if d[i] == 0 {
+			// Parallel to the slab
+			if p[i] < lower[i] || upper[i] < p[i] {
+				return RayCastOutput{}
+			}
+			continue
+		}
+
+		inv := 1 / d[i]
+		t1 := (lower[i] - p[i]) * inv
+		t2 := (upper[i] - p[i]) * inv
+
+		// The normal faces against the ray on the near side
+		s := -1.0
+		if t1 > t2 {
+			t1, t2 = t2, t1
+			s = 1
+		}
+		if t1 > tmin {
+			tmin = t1
+			normal = Point{}
+			if i == 0 {
+				normal.X = s
+			} else {
+				normal.Y = s
+			}
+		}
+		tmax = math.Min(tmax, t2)
+		if tmin > tmax {
+			return RayCastOutput{}
+		}
+	}
+
+	if tmin < 0 || input.MaxFraction < tmin {
+		return RayCastOutput{}
+	}
+	return RayCastOutput{
+		Point:    input.Origin.Add(input.Direction.Mul(tmin)),
+		Normal:   normal,
+		Fraction: tmin,
+		Hit:      true,
+	}
+}
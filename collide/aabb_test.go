@@ -0,0 +1,41 @@
+package collide
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestComputeAABB(t *testing.T) {
+	// The bounding boxes of known shapes agree with those from support points
+	r := rand.New(rand.NewSource(1))
+	box := &Box{Center: Point{1, 2}, Extents: Point{3, 1}}
+	shapes := []Shape{box, box.Polygon(), &Circle{Center: Point{1, 1}, Radius: 2}}
+	for i := 0; i < 1000; i++ {
+		xf := NewTransform(Point{r.Float64(), r.Float64()}, r.Float64()*7)
+		for _, s := range shapes {
+			got, want := ComputeAABB(s, xf), computeAABBConvex(s, xf)
+			if !approxEqualPoint(got.Min, want.Min) || !approxEqualPoint(got.Max, want.Max) {
+				t.Fatalf("%T: got bounding box %v, want %v", s, got, want)
+			}
+		}
+	}
+}
+
+func TestAABBRayCast(t *testing.T) {
+	// The slab method agrees with the ray cast against a box
+	r := rand.New(rand.NewSource(1))
+	box := &Box{Center: Point{1, 2}, Extents: Point{3, 1}}
+	origin := NewTransform(Point{}, 0)
+	aabb := ComputeAABB(box, origin)
+	for i := 0; i < 10000; i++ {
+		input := RayCastInput{
+			Origin:      Point{r.Float64()*20 - 10, r.Float64()*20 - 10},
+			Direction:   Point{r.Float64()*2 - 1, r.Float64()*2 - 1},
+			MaxFraction: r.Float64() * 20,
+		}
+		got, want := aabb.RayCast(&input), box.RayCast(origin, &input)
+		if got.Hit != want.Hit || got.Hit && (got.Normal != want.Normal || !approxEqualPoint(got.Point, want.Point)) {
+			t.Fatalf("got ray cast %v for %v, want %v", got, input, want)
+		}
+	}
+}
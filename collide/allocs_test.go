@@ -0,0 +1,218 @@
+package collide
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// allocCheck is a function that must not allocate.
+type allocCheck struct {
+	name string
+	fn   func()
+}
+
+// allocChecks returns the functions that must not allocate once warmed up.
+func allocChecks() []allocCheck {
+	circle := &Circle{Radius: 5}
+	polygon := Rect(0, 0, 10, 10)
+	box := &Box{Extents: Point{5, 5}}
+	rounded := &capsule{Point{-5, 0}, Point{5, 0}, 2}
+
+	origin := NewTransform(Point{0, 0}, 0)
+	near := NewTransform(Point{7, 2}, 0.5)
+	far := NewTransform(Point{30, 0}, 0.5)
+
+	// Out parameters owned by the caller
+	var collision Collision
+	var cache SimplexCache
+
+	var checks []allocCheck
+	for _, p := range []struct {
+		name string
+		a, b Shape
+	}{
+		{"circle-circle", circle, circle},
+		{"circle-polygon", circle, polygon},
+		{"polygon-polygon", polygon, polygon},
+		{"box-box", box, box},
+		{"box-circle", box, circle},
+		{"box-polygon", box, polygon},
+		{"capsule-polygon", rounded, polygon},
+	} {
+		p := p
+		checks = append(checks,
+			allocCheck{"CollideTo/" + p.name, func() {
+				CollideTo(&collision, p.a, origin, p.b, near)
+			}},
+			allocCheck{"CollideSpeculativeTo/" + p.name, func() {
+				CollideSpeculativeTo(&collision, p.a, origin, p.b, far, 20)
+			}},
+			allocCheck{"Collide/separated/" + p.name, func() {
+				Collide(p.a, origin, p.b, far)
+			}},
+			allocCheck{"Overlap/" + p.name, func() {
+				Overlap(p.a, origin, p.b, near)
+			}},
+			allocCheck{"ShapeDistance/" + p.name, func() {
+				ShapeDistance(&DistanceInput{
+					A:          p.a,
+					B:          p.b,
+					TransformA: origin,
+					TransformB: far,
+					UseRadii:   true,
+					Cache:      &cache,
+				})
+			}},
+			allocCheck{"TimeOfImpact/" + p.name, func() {
+				TimeOfImpact(&TOIInput{
+					A:      p.a,
+					B:      p.b,
+					SweepB: Sweep{P0: Point{30, 0}, R1: 1},
+				})
+			}},
+			allocCheck{"TimeOfImpactManifold/" + p.name, func() {
+				TimeOfImpactManifold(&TOIInput{
+					A:      p.a,
+					B:      p.b,
+					SweepB: Sweep{P0: Point{30, 0}, P1: Point{-30, 0}},
+				}, &collision)
+			}},
+			allocCheck{"ShapeCast/" + p.name, func() {
+				ShapeCast(&ShapeCastInput{
+					A:            p.a,
+					B:            p.b,
+					TransformA:   origin,
+					TransformB:   far,
+					TranslationB: Point{-30, 0},
+				})
+			}},
+		)
+	}
+	for _, s := range []Shape{circle, polygon, box, rounded} {
+		s := s
+		name := fmt.Sprintf("%T", s)
+		checks = append(checks,
+			allocCheck{"RayCast/" + name, func() {
+				RayCast(s, origin, &RayCastInput{
+					Origin:      Point{-20, 1},
+					Direction:   Point{1, 0},
+					MaxFraction: 40,
+				})
+			}},
+			allocCheck{"SignedDistance/" + name, func() {
+				SignedDistance(s, origin, Point{1, 20})
+			}},
+			allocCheck{"ComputeAABB/" + name, func() {
+				ComputeAABB(s, near)
+			}},
+		)
+	}
+
+	// Broadphase queries with callbacks that do not escape
+	r := rand.New(rand.NewSource(1))
+	tree := NewDynamicTree(nil)
+	for i := 0; i < 100; i++ {
+		tree.CreateProxy(randomAABB(r, 100), i)
+	}
+	query := AABB{Point{40, 40}, Point{60, 60}}
+	ray := RayCastInput{Origin: Point{-10, 50}, Direction: Point{1, 0}, MaxFraction: 120}
+	moving := AABB{Point{0, 0}, Point{1, 1}}
+	treeID := tree.CreateProxy(moving, nil)
+	var count int
+	checks = append(checks,
+		allocCheck{"DynamicTree.Query", func() {
+			tree.Query(query, func(id int) bool {
+				count++
+				return true
+			})
+		}},
+		allocCheck{"DynamicTree.RayCast", func() {
+			tree.RayCast(&ray, func(input RayCastInput, id int) float64 {
+				count++
+				return -1
+			})
+		}},
+		allocCheck{"DynamicTree.MoveProxy", func() {
+			// Move back and forth, reinserting the proxy each time
+			d := Point{50, 0}
+			if tree.FatAABB(treeID).Min.X > 10 {
+				d = d.Neg()
+			}
+			moving = AABB{moving.Min.Add(d), moving.Max.Add(d)}
+			tree.MoveProxy(treeID, moving, d)
+		}},
+	)
+
+	grid := NewHashGrid(4)
+	for i := 0; i < 100; i++ {
+		grid.Insert(circle, NewTransform(Point{r.Float64() * 100, r.Float64() * 100}, 0), i)
+	}
+	grid.Insert(Rect(0, 0, 200, 200), origin, nil)
+	gridID := grid.Insert(circle, origin, nil)
+	var pairs []Pair
+	checks = append(checks,
+		allocCheck{"HashGrid.Query", func() {
+			grid.Query(query, func(id int) bool {
+				count++
+				return true
+			})
+		}},
+		allocCheck{"HashGrid.QueryPoint", func() {
+			grid.QueryPoint(Point{50, 50}, func(id int) bool {
+				count++
+				return true
+			})
+		}},
+		allocCheck{"HashGrid.QueryPairs", func() {
+			grid.QueryPairs(func(idA, idB int) bool {
+				count++
+				return true
+			})
+		}},
+		allocCheck{"HashGrid.AppendPairs", func() {
+			pairs = grid.AppendPairs(pairs[:0])
+		}},
+		allocCheck{"HashGrid.Update", func() {
+			// Move back and forth between cells
+			_, xf := grid.Shape(gridID)
+			xf.Position = Point{50 - xf.Position.X, 0}
+			grid.Update(gridID, xf)
+		}},
+	)
+
+	bvh := NewBVH(bvhItems(1000))
+	checks = append(checks,
+		allocCheck{"BVH.Query", func() {
+			bvh.Query(query, func(index int) bool {
+				count++
+				return true
+			})
+		}},
+		allocCheck{"BVH.QueryPoint", func() {
+			bvh.QueryPoint(Point{50, 50}, func(index int) bool {
+				count++
+				return true
+			})
+		}},
+		allocCheck{"BVH.RayCast", func() {
+			bvh.RayCast(&ray)
+		}},
+		allocCheck{"BVH.ShapeCast", func() {
+			bvh.ShapeCast(&ShapeCastInput{
+				B:            circle,
+				TransformB:   NewTransform(Point{-10, 50}, 0),
+				TranslationB: Point{120, 0},
+			})
+		}},
+	)
+	return checks
+}
+
+func TestAllocs(t *testing.T) {
+	for _, check := range allocChecks() {
+		if allocs := testing.AllocsPerRun(100, check.fn); allocs != 0 {
+			t.Errorf("%s: got %v allocs per run, want 0", check.name, allocs)
+		}
+	}
+}
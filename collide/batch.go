@@ -0,0 +1,123 @@
+package collide
+
+import (
+	"runtime"
+	"sync"
+)
+
+// batchMinChunk is the minimum number of pairs handed to a worker at once.
+const batchMinChunk = 64
+
+// Pair represents a pair of shapes to collide.
+type Pair struct {
+	A, B                   Shape
+	TransformA, TransformB Transform
+}
+
+// A Batch calculates collisions for many pairs of shapes in parallel using
+// a pool of worker goroutines. It is safe for concurrent use.
+type Batch struct {
+	workers int
+	jobs    chan batchJob
+
+	// mu is held for reading while jobs are sent, and for writing while
+	// the batch is closed
+	mu     sync.RWMutex
+	closed bool
+}
+
+// batchJob is a chunk of pairs to collide.
+type batchJob struct {
+	pairs   []Pair
+	results []*Collision
+	margin  float64
+	wg      *sync.WaitGroup
+}
+
+// NewBatch returns a batch with the given number of workers.
+// If workers is less than 1, runtime.GOMAXPROCS(0) workers are used.
+// The workers run until Close is called.
+func NewBatch(workers int) *Batch {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	b := &Batch{
+		workers: workers,
+		jobs:    make(chan batchJob, workers),
+	}
+	for i := 0; i < workers; i++ {
+		go b.work()
+	}
+	return b
+}
+
+// Workers returns the number of workers of the batch.
+func (b *Batch) Workers() int {
+	return b.workers
+}
+
+// work collides the pairs of each job until the batch is closed.
+func (b *Batch) work() {
+	for job := range b.jobs {
+		for i := range job.pairs {
+			p := &job.pairs[i]
+			job.results[i] = CollideSpeculative(p.A, p.TransformA, p.B, p.TransformB, job.margin)
+		}
+		job.wg.Done()
+	}
+}
+
+// Collide calculates the collision of each pair and stores it in the result
+// with the same index, which is nil if the shapes do not overlap.
+// It panics if results is shorter than pairs or if the batch is closed.
+func (b *Batch) Collide(pairs []Pair, results []*Collision) {
+	b.CollideSpeculative(pairs, results, 0)
+}
+
+// CollideSpeculative is like Collide, but also calculates speculative
+// contacts for shapes separated by less than margin.
+func (b *Batch) CollideSpeculative(pairs []Pair, results []*Collision, margin float64) {
+	if len(results) < len(pairs) {
+		panic("collide: results shorter than pairs")
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		panic("collide: use of closed batch")
+	}
+
+	// Split the pairs into a few chunks per worker to balance the load
+	chunk := (len(pairs) + 4*b.workers - 1) / (4 * b.workers)
+	if chunk < batchMinChunk {
+		chunk = batchMinChunk
+	}
+
+	var wg sync.WaitGroup
+	for start := 0; start < len(pairs); start += chunk {
+		end := start + chunk
+		if end > len(pairs) {
+			end = len(pairs)
+		}
+		wg.Add(1)
+		b.jobs <- batchJob{
+			pairs:   pairs[start:end],
+			results: results[start:end],
+			margin:  margin,
+			wg:      &wg,
+		}
+	}
+	wg.Wait()
+}
+
+// Close stops the workers of the batch once the calls to Collide in progress
+// return. Calling Collide on a closed batch panics. Closing a batch more than
+// once has no effect.
+func (b *Batch) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		close(b.jobs)
+	}
+}
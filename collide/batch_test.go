@@ -0,0 +1,119 @@
+package collide
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// batchPairs returns n random pairs of shapes, about half of which overlap.
+func batchPairs(n int) []Pair {
+	r := rand.New(rand.NewSource(1))
+	shapes := []Shape{
+		&Circle{Radius: 5},
+		Rect(0, 0, 10, 10),
+		&Box{Extents: Point{5, 5}},
+		NewPolygon(Point{-5, -5}, Point{5, -5}, Point{0, 5}),
+	}
+	pairs := make([]Pair, n)
+	for i := range pairs {
+		pairs[i] = Pair{
+			A:          shapes[r.Intn(len(shapes))],
+			B:          shapes[r.Intn(len(shapes))],
+			TransformA: NewTransform(Point{0, 0}, 0),
+			TransformB: NewTransform(Point{r.Float64()*24 - 12, r.Float64()*24 - 12}, r.Float64()*6),
+		}
+	}
+	return pairs
+}
+
+func TestBatch(t *testing.T) {
+	pairs := batchPairs(5000)
+	batch := NewBatch(4)
+	defer batch.Close()
+
+	// Collide the pairs from several goroutines at once
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(margin float64) {
+			defer wg.Done()
+			results := make([]*Collision, len(pairs))
+			batch.CollideSpeculative(pairs, results, margin)
+			for i, p := range pairs {
+				want := CollideSpeculative(p.A, p.TransformA, p.B, p.TransformB, margin)
+				if (results[i] == nil) != (want == nil) || want != nil && *results[i] != *want {
+					t.Errorf("pair %d: got collision %v, want %v", i, results[i], want)
+					return
+				}
+			}
+		}(float64(g))
+	}
+	wg.Wait()
+}
+
+func TestBatchClose(t *testing.T) {
+	batch := NewBatch(2)
+	batch.Close()
+
+	// Closing again has no effect
+	batch.Close()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Collide did not panic on a closed batch")
+		}
+	}()
+	batch.Collide(batchPairs(10), make([]*Collision, 10))
+}
+
+func TestBatchCloseConcurrent(t *testing.T) {
+	// Close waits for the calls in progress, and later calls panic
+	// instead of sending on the closed channel of jobs
+	pairs := batchPairs(1000)
+	batch := NewBatch(2)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil && r != "collide: use of closed batch" {
+					t.Errorf("got panic %v", r)
+				}
+			}()
+			results := make([]*Collision, len(pairs))
+			for i := 0; i < 10; i++ {
+				batch.Collide(pairs, results)
+			}
+		}()
+	}
+	batch.Close()
+	wg.Wait()
+}
+
+func BenchmarkBatch(b *testing.B) {
+	pairs := batchPairs(50000)
+	results := make([]*Collision, len(pairs))
+	var counts []int
+	for workers := 1; workers < runtime.NumCPU(); workers *= 2 {
+		counts = append(counts, workers)
+	}
+	counts = append(counts, runtime.NumCPU())
+
+	for _, workers := range counts {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			batch := NewBatch(workers)
+			defer batch.Close()
+			start := time.Now()
+			for i := 0; i < b.N; i++ {
+				batch.Collide(pairs, results)
+			}
+			perPair := float64(time.Since(start).Nanoseconds()) / float64(b.N*len(pairs))
+			b.ReportMetric(perPair, "ns/pair")
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package collide
+
+import (
+	"testing"
+)
+
+// benchmarkPair is a pair of shapes to benchmark.
+type benchmarkPair struct {
+	name     string
+	a, b     Shape
+	xfa, xfb Transform
+}
+
+// benchmarkPairs returns overlapping and separated pairs of each
+// combination of shape types.
+func benchmarkPairs() []benchmarkPair {
+	circle := &Circle{Radius: 5}
+	square := Rect(0, 0, 10, 10)
+	box := &Box{Extents: Point{5, 5}}
+	triangle := NewPolygon(Point{-5, -5}, Point{5, -5}, Point{0, 5})
+
+	origin := NewTransform(Point{0, 0}, 0)
+	near := NewTransform(Point{7, 2}, 0.5)
+	far := NewTransform(Point{30, 0}, 0.5)
+	nearAligned := NewTransform(Point{7, 2}, 0)
+	farAligned := NewTransform(Point{30, 0}, 0)
+
+	var pairs []benchmarkPair
+	for _, p := range []struct {
+		name      string
+		a, b      Shape
+		near, far Transform
+	}{
+		{"circle-circle", circle, circle, near, far},
+		{"circle-polygon", circle, square, near, far},
+		{"polygon-circle", square, circle, near, far},
+		{"polygon-polygon", square, triangle, near, far},
+		{"polygon-polygon-aligned", square, square, nearAligned, farAligned},
+		{"box-box", box, box, nearAligned, farAligned},
+		{"box-circle", box, circle, near, far},
+		{"box-polygon", box, triangle, near, far},
+		{"convex-convex", hull{square}, hull{triangle}, near, far},
+	} {
+		pairs = append(pairs,
+			benchmarkPair{p.name + "/overlapping", p.a, p.b, origin, p.near},
+			benchmarkPair{p.name + "/separated", p.a, p.b, origin, p.far},
+		)
+	}
+	return pairs
+}
+
+func BenchmarkCollide(b *testing.B) {
+	for _, p := range benchmarkPairs() {
+		p := p
+		b.Run(p.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				Collide(p.a, p.xfa, p.b, p.xfb)
+			}
+		})
+	}
+}
+
+func BenchmarkCollideTo(b *testing.B) {
+	for _, p := range benchmarkPairs() {
+		p := p
+		b.Run(p.name, func(b *testing.B) {
+			b.ReportAllocs()
+			var c Collision
+			for i := 0; i < b.N; i++ {
+				CollideTo(&c, p.a, p.xfa, p.b, p.xfb)
+			}
+		})
+	}
+}
+
+func BenchmarkCollideOverlap(b *testing.B) {
+	for _, p := range benchmarkPairs() {
+		p := p
+		b.Run(p.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				Overlap(p.a, p.xfa, p.b, p.xfb)
+			}
+		})
+	}
+}
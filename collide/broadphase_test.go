@@ -0,0 +1,200 @@
+package collide
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// broadphaseScene is a set of boxes moving in a square world.
+type broadphaseScene struct {
+	box    *Box
+	xfs    []Transform
+	vels   []Point
+	size   float64
+	rand   *rand.Rand
+	random bool
+}
+
+// newBroadphaseScene returns a scene of n boxes moving at the given speed.
+// If random is true, the boxes move to random positions every step instead.
+func newBroadphaseScene(n int, size, speed float64, random bool) *broadphaseScene {
+	s := &broadphaseScene{
+		box:    &Box{Extents: Point{1, 1}},
+		xfs:    make([]Transform, n),
+		vels:   make([]Point, n),
+		size:   size,
+		rand:   rand.New(rand.NewSource(1)),
+		random: random,
+	}
+	for i := range s.xfs {
+		s.xfs[i] = NewTransform(s.randomPoint(), 0)
+		s.vels[i] = Point{s.rand.Float64()*2 - 1, s.rand.Float64()*2 - 1}.Mul(speed)
+	}
+	return s
+}
+
+func (s *broadphaseScene) randomPoint() Point {
+	return Point{s.rand.Float64() * s.size, s.rand.Float64() * s.size}
+}
+
+// step moves the boxes, bouncing them off the edges of the world.
+func (s *broadphaseScene) step() {
+	for i := range s.xfs {
+		if s.random {
+			s.xfs[i].Position = s.randomPoint()
+			continue
+		}
+		p := s.xfs[i].Position.Add(s.vels[i])
+		if p.X < 0 || p.X > s.size {
+			s.vels[i].X = -s.vels[i].X
+		}
+		if p.Y < 0 || p.Y > s.size {
+			s.vels[i].Y = -s.vels[i].Y
+		}
+		s.xfs[i].Position = p
+	}
+}
+
+func (s *broadphaseScene) aabb(i int) AABB {
+	return ComputeAABB(s.box, s.xfs[i])
+}
+
+// broadphase finds the pairs of overlapping boxes of a scene.
+type broadphase struct {
+	name string
+	// init adds the boxes of the scene.
+	init func(s *broadphaseScene)
+	// step updates the boxes after the scene moved, and returns the number
+	// of overlapping pairs.
+	step func(s *broadphaseScene) int
+}
+
+func broadphases() []broadphase {
+	var tree *DynamicTree
+	var treeIDs []int
+	var grid *HashGrid
+	var gridIDs []int
+	var sap *SweepAndPrune
+	var sapIDs []int
+
+	return []broadphase{
+		{
+			name: "Tree",
+			init: func(s *broadphaseScene) {
+				tree = NewDynamicTree(nil)
+				treeIDs = treeIDs[:0]
+				for i := range s.xfs {
+					treeIDs = append(treeIDs, tree.CreateProxy(s.aabb(i), i))
+				}
+			},
+			step: func(s *broadphaseScene) int {
+				for i, id := range treeIDs {
+					tree.MoveProxy(id, s.aabb(i), s.vels[i])
+				}
+				pairs := 0
+				for i, id := range treeIDs {
+					a := s.aabb(i)
+					tree.Query(a, func(other int) bool {
+						if other > id && a.Overlaps(s.aabb(tree.UserData(other).(int))) {
+							pairs++
+						}
+						return true
+					})
+				}
+				return pairs
+			},
+		},
+		{
+			name: "Grid",
+			init: func(s *broadphaseScene) {
+				grid = NewHashGrid(4)
+				gridIDs = gridIDs[:0]
+				for i := range s.xfs {
+					gridIDs = append(gridIDs, grid.Insert(s.box, s.xfs[i], i))
+				}
+			},
+			step: func(s *broadphaseScene) int {
+				for i, id := range gridIDs {
+					grid.Update(id, s.xfs[i])
+				}
+				pairs := 0
+				grid.QueryPairs(func(idA, idB int) bool {
+					pairs++
+					return true
+				})
+				return pairs
+			},
+		},
+		{
+			name: "SAP",
+			init: func(s *broadphaseScene) {
+				sap = NewSweepAndPrune()
+				sapIDs = sapIDs[:0]
+				for i := range s.xfs {
+					sapIDs = append(sapIDs, sap.Insert(s.aabb(i), i))
+				}
+				sap.ClearEvents()
+			},
+			step: func(s *broadphaseScene) int {
+				for i, id := range sapIDs {
+					sap.Update(id, s.aabb(i))
+				}
+				sap.ClearEvents()
+				return sap.PairCount()
+			},
+		},
+	}
+}
+
+func TestBroadphases(t *testing.T) {
+	// The broadphases find the same pairs as testing every pair
+	for _, random := range []bool{false, true} {
+		for _, bp := range broadphases() {
+			s := newBroadphaseScene(300, 50, 0.5, random)
+			bp.init(s)
+			for step := 0; step < 10; step++ {
+				s.step()
+				want := 0
+				for i := range s.xfs {
+					for j := i + 1; j < len(s.xfs); j++ {
+						if s.aabb(i).Overlaps(s.aabb(j)) {
+							want++
+						}
+					}
+				}
+				if got := bp.step(s); got != want {
+					t.Fatalf("%s: got %d pairs at step %d, want %d", bp.name, got, step, want)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkBroadphase(b *testing.B) {
+	scenes := []struct {
+		name   string
+		speed  float64
+		random bool
+	}{
+		{"coherent", 0.05, false},
+		{"random", 0, true},
+	}
+	for _, scene := range scenes {
+		scene := scene
+		b.Run(scene.name, func(b *testing.B) {
+			for _, bp := range broadphases() {
+				bp := bp
+				b.Run(bp.name, func(b *testing.B) {
+					s := newBroadphaseScene(2000, 200, scene.speed, scene.random)
+					bp.init(s)
+					b.ReportAllocs()
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						s.step()
+						bp.step(s)
+					}
+				})
+			}
+		})
+	}
+}
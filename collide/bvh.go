@@ -0,0 +1,543 @@
+package collide
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+const (
+	// bvhBins is the number of bins used to evaluate split planes.
+	bvhBins = 16
+	// bvhMaxLeaf is the maximum number of items in a leaf.
+	bvhMaxLeaf = 4
+)
+
+// BVHItem is a shape stored in a BVH.
+type BVHItem struct {
+	Shape     Shape
+	Transform Transform
+}
+
+// bvhNode is a node of a BVH. The nodes are stored in depth-first order,
+// so the first child of an inner node immediately follows it.
+type bvhNode struct {
+	aabb AABB
+	// index is the index of the second child of an inner node, or the index
+	// of the first item of a leaf in the item order
+	index int
+	// count is the number of items of a leaf, or zero for inner nodes
+	count int
+}
+
+// A BVH is a static bounding volume hierarchy over shapes that never move,
+// such as level geometry. It is built once, top-down, choosing the splits
+// with the surface area heuristic, and is not updated afterwards. Queries
+// report items by their index in the slice passed to NewBVH.
+//
+// Building a large BVH takes time, so it can be built ahead of time and
+// stored with MarshalBinary, then loaded with LoadBVH.
+type BVH struct {
+	items []BVHItem
+	aabbs []AABB
+	nodes []bvhNode
+	order []int // item indices in leaf order
+}
+
+// NewBVH builds a hierarchy over the items. The items must not be modified
+// while the hierarchy is in use.
+func NewBVH(items []BVHItem) *BVH {
+	b := &BVH{
+		items: items,
+		aabbs: computeItemAABBs(items),
+		order: make([]int, len(items)),
+	}
+	if len(items) == 0 {
+		return b
+	}
+
+	centers := make([]Point, len(items))
+	for i := range items {
+		b.order[i] = i
+		centers[i] = b.aabbs[i].Center()
+	}
+	b.nodes = make([]bvhNode, 0, 2*len(items)/bvhMaxLeaf+1)
+	b.build(0, len(items), centers)
+	return b
+}
+
+// computeItemAABBs returns the bounding boxes of the items.
+func computeItemAABBs(items []BVHItem) []AABB {
+	aabbs := make([]AABB, len(items))
+	for i := range items {
+		aabbs[i] = ComputeAABB(items[i].Shape, items[i].Transform)
+	}
+	return aabbs
+}
+
+// bvhBin accumulates the items whose centers fall in a bin.
+type bvhBin struct {
+	aabb  AABB
+	count int
+}
+
+// build builds the subtree over the items order[start:end] and returns the
+// index of its root.
+func (b *BVH) build(start, end int, centers []Point) int {
+	id := len(b.nodes)
+	b.nodes = append(b.nodes, bvhNode{})
+
+	aabb := b.aabbs[b.order[start]]
+	bounds := AABB{centers[b.order[start]], centers[b.order[start]]}
+	for _, i := range b.order[start+1 : end] {
+		aabb = aabb.Union(b.aabbs[i])
+		bounds = bounds.addPoint(centers[i])
+	}
+	b.nodes[id].aabb = aabb
+
+	n := end - start
+	if n <= bvhMaxLeaf {
+		b.nodes[id].index = start
+		b.nodes[id].count = n
+		return id
+	}
+
+	// Find the split plane between bins with the lowest cost, which is the
+	// sum of the perimeters of the children weighted by their item counts
+	bestAxis, bestSplit := -1, 0
+	bestCost := math.Inf(1)
+	for axis := 0; axis < 2; axis++ {
+		lo, hi := bound(&bounds, axis, false), bound(&bounds, axis, true)
+		if hi <= lo {
+			continue
+		}
+		scale := bvhBins / (hi - lo)
+
+		var bins [bvhBins]bvhBin
+		for _, i := range b.order[start:end] {
+			bin := binOf(centers[i], axis, lo, scale)
+			if bins[bin].count == 0 {
+				bins[bin].aabb = b.aabbs[i]
+			} else {
+				bins[bin].aabb = bins[bin].aabb.Union(b.aabbs[i])
+			}
+			bins[bin].count++
+		}
+
+		// Sweep from the right to find the costs of the right children
+		var rightCost [bvhBins]float64
+		var right bvhBin
+		for i := bvhBins - 1; i > 0; i-- {
+			right = right.add(bins[i])
+			rightCost[i] = float64(right.count) * right.aabb.Perimeter()
+		}
+
+		// Sweep from the left, splitting between bins i-1 and i
+		var left bvhBin
+		for i := 1; i < bvhBins; i++ {
+			left = left.add(bins[i-1])
+			if left.count == 0 || left.count == n {
+				continue
+			}
+			cost := float64(left.count)*left.aabb.Perimeter() + rightCost[i]
+			if cost < bestCost {
+				bestAxis, bestSplit = axis, i
+				bestCost = cost
+			}
+		}
+	}
+
+	// Partition the items, or split them in half if their centers coincide
+	mid := start + n/2
+	if bestAxis >= 0 {
+		lo := bound(&bounds, bestAxis, false)
+		scale := bvhBins / (bound(&bounds, bestAxis, true) - lo)
+		mid = start
+		for i := start; i < end; i++ {
+			if binOf(centers[b.order[i]], bestAxis, lo, scale) < bestSplit {
+				b.order[i], b.order[mid] = b.order[mid], b.order[i]
+				mid++
+			}
+		}
+	}
+
+	b.build(start, mid, centers)
+	b.nodes[id].index = b.build(mid, end, centers)
+	return id
+}
+
+// binOf returns the bin of a center on an axis.
+func binOf(center Point, axis int, lo, scale float64) int {
+	c := center.X
+	if axis == 1 {
+		c = center.Y
+	}
+	bin := int((c - lo) * scale)
+	if bin >= bvhBins {
+		bin = bvhBins - 1
+	}
+	return bin
+}
+
+// add returns the bin containing the items of both bins.
+func (b bvhBin) add(other bvhBin) bvhBin {
+	switch {
+	case other.count == 0:
+		return b
+	case b.count == 0:
+		return other
+	}
+	return bvhBin{b.aabb.Union(other.aabb), b.count + other.count}
+}
+
+// Len returns the number of items in the hierarchy.
+func (b *BVH) Len() int {
+	return len(b.items)
+}
+
+// Item returns the item with the given index.
+func (b *BVH) Item(index int) BVHItem {
+	return b.items[index]
+}
+
+// Bounds returns the bounding box of all items.
+func (b *BVH) Bounds() AABB {
+	if len(b.nodes) == 0 {
+		return AABB{}
+	}
+	return b.nodes[0].aabb
+}
+
+// Query calls callback with the index of each item whose bounding box
+// overlaps aabb. The query stops if callback returns false.
+func (b *BVH) Query(aabb AABB, callback func(index int) bool) {
+	if len(b.nodes) == 0 {
+		return
+	}
+	var buf [treeStackSize]int
+	stack := append(buf[:0], 0)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		n := &b.nodes[id]
+		if !n.aabb.Overlaps(aabb) {
+			continue
+		}
+		if n.count == 0 {
+			stack = append(stack, n.index, id+1)
+			continue
+		}
+		for _, i := range b.order[n.index : n.index+n.count] {
+			if b.aabbs[i].Overlaps(aabb) && !callback(i) {
+				return
+			}
+		}
+	}
+}
+
+// QueryPoint calls callback with the index of each item whose shape contains
+// the point. The query stops if callback returns false.
+func (b *BVH) QueryPoint(p Point, callback func(index int) bool) {
+	b.Query(AABB{p, p}, func(index int) bool {
+		item := &b.items[index]
+		if !TestPoint(item.Shape, item.Transform, p) {
+			return true
+		}
+		return callback(index)
+	})
+}
+
+// RayCast casts a ray against the items and returns the closest hit, along
+// with the index of the item that was hit, or -1 if the ray hit nothing.
+// Rays that start inside a shape do not hit it.
+func (b *BVH) RayCast(input *RayCastInput) (RayCastOutput, int) {
+	var output RayCastOutput
+	hit := -1
+	if len(b.nodes) == 0 {
+		return output, hit
+	}
+
+	sub := *input
+	var buf [treeStackSize]int
+	stack := append(buf[:0], 0)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		n := &b.nodes[id]
+		if _, ok := segmentOverlaps(&n.aabb, sub.Origin, sub.Direction, sub.MaxFraction); !ok {
+			continue
+		}
+		if n.count == 0 {
+			// Visit the nearer child first, so that its hits clip the ray
+			// before the farther child is tested
+			near, far := id+1, n.index
+			tNear, okNear := segmentOverlaps(&b.nodes[near].aabb, sub.Origin, sub.Direction, sub.MaxFraction)
+			tFar, okFar := segmentOverlaps(&b.nodes[far].aabb, sub.Origin, sub.Direction, sub.MaxFraction)
+			if okNear && okFar && tFar < tNear {
+				near, far = far, near
+			}
+			if okFar {
+				stack = append(stack, far)
+			}
+			if okNear {
+				stack = append(stack, near)
+			}
+			continue
+		}
+		for _, i := range b.order[n.index : n.index+n.count] {
+			if _, ok := segmentOverlaps(&b.aabbs[i], sub.Origin, sub.Direction, sub.MaxFraction); !ok {
+				continue
+			}
+			item := &b.items[i]
+			if o := RayCast(item.Shape, item.Transform, &sub); o.Hit {
+				// Clip the ray to the hit
+				output, hit = o, i
+				sub.MaxFraction = o.Fraction
+			}
+		}
+	}
+	return output, hit
+}
+
+// ShapeCast computes the first contact of shape input.B moving along
+// input.TranslationB with the items, and returns the index of the item
+// that was hit, or -1 if the shape hit nothing. input.A and
+// input.TransformA are ignored. The output is that of ShapeCast with the
+// item as shape A.
+func (b *BVH) ShapeCast(input *ShapeCastInput) (ShapeCastOutput, int) {
+	var output ShapeCastOutput
+	hit := -1
+	if len(b.nodes) == 0 {
+		return output, hit
+	}
+
+	// Cast the center of the bounding box of the shape against the bounding
+	// boxes of the nodes grown by its extents. ShapeCast reports contact
+	// within LinearSlop, so grow them by that as well.
+	settings := settingsOrDefault(input.Settings)
+	aabb := ComputeAABB(input.B, input.TransformB)
+	center := aabb.Center()
+	extents := aabb.Extents().Add(Point{settings.LinearSlop, settings.LinearSlop})
+	translation := input.TranslationB
+	maxFraction := 1.0
+
+	sub := *input
+	var buf [treeStackSize]int
+	stack := append(buf[:0], 0)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		n := &b.nodes[id]
+		grown := AABB{n.aabb.Min.Sub(extents), n.aabb.Max.Add(extents)}
+		if _, ok := segmentOverlaps(&grown, center, translation, maxFraction); !ok {
+			continue
+		}
+		if n.count == 0 {
+			stack = append(stack, n.index, id+1)
+			continue
+		}
+		for _, i := range b.order[n.index : n.index+n.count] {
+			grown := AABB{b.aabbs[i].Min.Sub(extents), b.aabbs[i].Max.Add(extents)}
+			if _, ok := segmentOverlaps(&grown, center, translation, maxFraction); !ok {
+				continue
+			}
+			sub.A = b.items[i].Shape
+			sub.TransformA = b.items[i].Transform
+			o := ShapeCast(&sub)
+			if !o.Hit || o.Fraction > maxFraction || hit >= 0 && o.Fraction == maxFraction {
+				continue
+			}
+			output, hit = o, i
+			maxFraction = o.Fraction
+			if o.Fraction == 0 {
+				// Nothing can be hit earlier
+				return output, hit
+			}
+		}
+	}
+	return output, hit
+}
+
+// segmentOverlaps reports whether the segment from p to p+d*maxFraction
+// overlaps the bounding box, and returns the fraction at which it enters
+// the box.
+func segmentOverlaps(a *AABB, p, d Point, maxFraction float64) (float64, bool) {
+	tmin, tmax := 0.0, maxFraction
+	for axis := 0; axis < 2; axis++ {
+		pi, di := p.X, d.X
+		if axis == 1 {
+			pi, di = p.Y, d.Y
+		}
+		lo, hi := bound(a, axis, false), bound(a, axis, true)
+		if di == 0 {
+			if pi < lo || hi < pi {
+				return 0, false
+			}
+			continue
+		}
+		t1 := (lo - pi) / di
+		t2 := (hi - pi) / di
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		tmin = math.Max(tmin, t1)
+		tmax = math.Min(tmax, t2)
+		if tmin > tmax {
+			return 0, false
+		}
+	}
+	return tmin, true
+}
+
+// bvhMagic identifies the binary encoding of a BVH.
+var bvhMagic = [4]byte{'B', 'V', 'H', '1'}
+
+// bvhNodeSize is the size of an encoded node: four coordinates and two
+// integers.
+const bvhNodeSize = 4*8 + 2*4
+
+// MarshalBinary encodes the hierarchy. The items are not encoded, since
+// shapes may be of any type, so they must be passed to LoadBVH in the same
+// order.
+//
+// The encoding is little-endian: the magic "BVH1", the number of items and
+// the number of nodes as uint32, then the nodes as four float64 coordinates
+// and two uint32 indices each, then the item order as uint32 indices.
+func (b *BVH) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 0, 12+len(b.nodes)*bvhNodeSize+len(b.order)*4)
+	data = append(data, bvhMagic[:]...)
+	data = appendUint32(data, len(b.items))
+	data = appendUint32(data, len(b.nodes))
+	for _, n := range b.nodes {
+		for _, f := range [4]float64{n.aabb.Min.X, n.aabb.Min.Y, n.aabb.Max.X, n.aabb.Max.Y} {
+			var buf [8]byte
+			binary.LittleEndian.PutUint64(buf[:], math.Float64bits(f))
+			data = append(data, buf[:]...)
+		}
+		data = appendUint32(data, n.index)
+		data = appendUint32(data, n.count)
+	}
+	for _, i := range b.order {
+		data = appendUint32(data, i)
+	}
+	return data, nil
+}
+
+// appendUint32 appends the little-endian encoding of v to data.
+func appendUint32(data []byte, v int) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], uint32(v))
+	return append(data, buf[:]...)
+}
+
+// LoadBVH decodes a hierarchy encoded by MarshalBinary over the items, which
+// must be the items it was built from, in the same order. It returns an
+// error if the data is not a valid hierarchy whose nodes bound the items.
+func LoadBVH(data []byte, items []BVHItem) (*BVH, error) {
+	if len(data) < 12 || string(data[:4]) != string(bvhMagic[:]) {
+		return nil, errors.New("collide: invalid BVH encoding")
+	}
+
+	// Compare the counts before converting them, so that they cannot
+	// overflow an int. The size cannot overflow a uint64.
+	items32 := binary.LittleEndian.Uint32(data[4:])
+	nodes32 := binary.LittleEndian.Uint32(data[8:])
+	if uint64(items32) != uint64(len(items)) {
+		return nil, errors.New("collide: BVH item count mismatch")
+	}
+	if uint64(len(data)) != 12+uint64(nodes32)*bvhNodeSize+uint64(items32)*4 {
+		return nil, errors.New("collide: invalid BVH encoding")
+	}
+	if (nodes32 == 0) != (items32 == 0) {
+		return nil, errors.New("collide: invalid BVH encoding")
+	}
+	itemCount, nodeCount := int(items32), int(nodes32)
+
+	b := &BVH{
+		items: items,
+		aabbs: computeItemAABBs(items),
+		nodes: make([]bvhNode, nodeCount),
+		order: make([]int, itemCount),
+	}
+	data = data[12:]
+	for id := range b.nodes {
+		var f [4]float64
+		for i := range f {
+			f[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:]))
+		}
+		index := uint64(binary.LittleEndian.Uint32(data[32:]))
+		count := uint64(binary.LittleEndian.Uint32(data[36:]))
+		data = data[bvhNodeSize:]
+
+		// Inner nodes must point forward so that traversal terminates
+		if count == 0 && (index <= uint64(id)+1 || index >= uint64(nodeCount)) ||
+			count > 0 && index+count > uint64(itemCount) {
+			return nil, errors.New("collide: invalid BVH node")
+		}
+		b.nodes[id] = bvhNode{
+			aabb:  AABB{Point{f[0], f[1]}, Point{f[2], f[3]}},
+			index: int(index),
+			count: int(count),
+		}
+	}
+
+	// The order must be a permutation of the items
+	seen := make([]uint64, (itemCount+63)/64)
+	for i := range b.order {
+		index := binary.LittleEndian.Uint32(data[4*i:])
+		if uint64(index) >= uint64(itemCount) || seen[index/64]&(1<<(index%64)) != 0 {
+			return nil, errors.New("collide: invalid BVH item index")
+		}
+		seen[index/64] |= 1 << (index % 64)
+		b.order[i] = int(index)
+	}
+	if !b.valid() {
+		return nil, errors.New("collide: invalid BVH tree")
+	}
+	return b, nil
+}
+
+// valid reports whether the nodes form a tree in depth-first order, whose
+// leaves cover the item order in turn, and whether each node contains its
+// children or the items of its leaf. Every node must be reached exactly
+// once, which rules out shared children and cycles.
+func (b *BVH) valid() bool {
+	if len(b.nodes) == 0 {
+		return true
+	}
+	visited := make([]uint64, (len(b.nodes)+63)/64)
+	reached := 0
+	next := 0 // first item of the next leaf
+	stack := []int{0}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if visited[id/64]&(1<<(id%64)) != 0 {
+			return false
+		}
+		visited[id/64] |= 1 << (id % 64)
+		reached++
+
+		n := &b.nodes[id]
+		if n.count > 0 {
+			if n.index != next {
+				return false
+			}
+			next += n.count
+			for _, i := range b.order[n.index:next] {
+				if !n.aabb.Contains(b.aabbs[i]) {
+					return false
+				}
+			}
+			continue
+		}
+		if !n.aabb.Contains(b.nodes[id+1].aabb) || !n.aabb.Contains(b.nodes[n.index].aabb) {
+			return false
+		}
+		stack = append(stack, n.index, id+1)
+	}
+	return reached == len(b.nodes) && next == len(b.order)
+}
@@ -0,0 +1,270 @@
+package collide
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// bvhItems returns n random items, followed by a few items at the same
+// position.
+func bvhItems(n int) []BVHItem {
+	r := rand.New(rand.NewSource(1))
+	shapes := []Shape{
+		&Circle{Radius: 1},
+		&Box{Extents: Point{2, 0.5}},
+		Rect(0, 0, 3, 1),
+		NewPolygon(Point{-1, -1}, Point{1, -1}, Point{0, 1}),
+	}
+	items := make([]BVHItem, 0, n+10)
+	for i := 0; i < n; i++ {
+		xf := NewTransform(Point{r.Float64() * 200, r.Float64() * 200}, r.Float64()*7)
+		items = append(items, BVHItem{shapes[r.Intn(len(shapes))], xf})
+	}
+	for i := 0; i < 10; i++ {
+		items = append(items, BVHItem{shapes[0], NewTransform(Point{50, 50}, 0)})
+	}
+	return items
+}
+
+func TestBVH(t *testing.T) {
+	// Queries agree with testing every item
+	r := rand.New(rand.NewSource(2))
+	items := bvhItems(2000)
+	bvh := NewBVH(items)
+	if bvh.Len() != len(items) {
+		t.Fatalf("got %d items, want %d", bvh.Len(), len(items))
+	}
+	for i := range items {
+		if !bvh.Bounds().Contains(ComputeAABB(items[i].Shape, items[i].Transform)) {
+			t.Fatalf("bounds %v do not contain item %d", bvh.Bounds(), i)
+		}
+	}
+	shapes := []Shape{&Circle{Radius: 0.5}, Rect(0, 0, 1, 2)}
+	for q := 0; q < 200; q++ {
+		p := Point{r.Float64() * 200, r.Float64() * 200}
+		query := AABB{p, p.Add(Point{r.Float64() * 10, r.Float64() * 10})}
+		found := map[int]bool{}
+		bvh.Query(query, func(index int) bool {
+			found[index] = true
+			return true
+		})
+		for i, item := range items {
+			if want := ComputeAABB(item.Shape, item.Transform).Overlaps(query); found[i] != want {
+				t.Fatalf("got found %v for item %d, want %v", found[i], i, want)
+			}
+		}
+
+		found = map[int]bool{}
+		bvh.QueryPoint(p, func(index int) bool {
+			found[index] = true
+			return true
+		})
+		for i, item := range items {
+			if want := TestPoint(item.Shape, item.Transform, p); found[i] != want {
+				t.Fatalf("got point found %v for item %d, want %v", found[i], i, want)
+			}
+		}
+
+		input := RayCastInput{
+			Origin:      Point{r.Float64() * 200, r.Float64() * 200},
+			Direction:   Point{r.Float64() - 0.5, r.Float64() - 0.5},
+			MaxFraction: 100,
+		}
+		want, wantIndex := RayCastOutput{}, -1
+		for i, item := range items {
+			if output := RayCast(item.Shape, item.Transform, &input); output.Hit && (wantIndex < 0 || output.Fraction < want.Fraction) {
+				want, wantIndex = output, i
+			}
+		}
+		// Items at the same position may be hit at the same fraction
+		got, index := bvh.RayCast(&input)
+		if (index < 0) != (wantIndex < 0) || got != want ||
+			index >= 0 && RayCast(items[index].Shape, items[index].Transform, &input) != got {
+			t.Fatalf("got ray cast %v of item %d, want %v of item %d", got, index, want, wantIndex)
+		}
+
+		cast := ShapeCastInput{
+			B:            shapes[r.Intn(len(shapes))],
+			TransformB:   NewTransform(input.Origin, r.Float64()),
+			TranslationB: input.Direction.Mul(60),
+		}
+		wantCast, wantIndex := ShapeCastOutput{}, -1
+		for i, item := range items {
+			c := cast
+			c.A, c.TransformA = item.Shape, item.Transform
+			if output := ShapeCast(&c); output.Hit && (wantIndex < 0 || output.Fraction < wantCast.Fraction) {
+				wantCast, wantIndex = output, i
+			}
+		}
+		gotCast, index := bvh.ShapeCast(&cast)
+		if (index < 0) != (wantIndex < 0) || index >= 0 && gotCast.Fraction != wantCast.Fraction {
+			t.Fatalf("got shape cast %v of item %d, want %v of item %d", gotCast, index, wantCast, wantIndex)
+		}
+	}
+}
+
+func TestBVHMarshal(t *testing.T) {
+	for _, n := range []int{0, 1, 3, 500} {
+		items := bvhItems(n)
+		if n == 0 {
+			items = nil
+		}
+		bvh := NewBVH(items)
+		data, err := bvh.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%d items: %v", n, err)
+		}
+		loaded, err := LoadBVH(data, items)
+		if err != nil {
+			t.Fatalf("%d items: %v", n, err)
+		}
+		if len(loaded.nodes) != len(bvh.nodes) || len(loaded.order) != len(bvh.order) ||
+			n > 0 && (!reflect.DeepEqual(loaded.nodes, bvh.nodes) || !reflect.DeepEqual(loaded.order, bvh.order)) {
+			t.Errorf("%d items: loaded hierarchy differs", n)
+		}
+	}
+}
+
+func TestLoadBVHInvalid(t *testing.T) {
+	items := bvhItems(100)
+	data, _ := NewBVH(items).MarshalBinary()
+	nodeCount := int(binary.LittleEndian.Uint32(data[8:]))
+	orderOffset := 12 + nodeCount*bvhNodeSize
+	if binary.LittleEndian.Uint32(data[12+bvhNodeSize+36:]) != 0 {
+		t.Fatal("got a leaf as the first child of the root, want an inner node")
+	}
+	rootIndex := data[12+32 : 12+36]
+	var leaves []int
+	for id := 0; id < nodeCount; id++ {
+		if binary.LittleEndian.Uint32(data[12+id*bvhNodeSize+36:]) > 0 {
+			leaves = append(leaves, 12+id*bvhNodeSize)
+		}
+	}
+	reversed := make([]BVHItem, len(items))
+	for i := range items {
+		reversed[len(items)-1-i] = items[i]
+	}
+
+	// modified returns a copy of the encoding changed by fn
+	modified := func(fn func(data []byte)) []byte {
+		d := append([]byte(nil), data...)
+		fn(d)
+		return d
+	}
+	tests := []struct {
+		name  string
+		data  []byte
+		items []BVHItem
+	}{
+		{"empty", nil, items},
+		{"bad magic", modified(func(d []byte) { d[0] = 'X' }), items},
+		{"truncated", data[:len(data)-1], items},
+		{"trailing data", append(append([]byte(nil), data...), 0), items},
+		{"fewer items", data, items[:len(items)-1]},
+		{"huge item count", modified(func(d []byte) { binary.LittleEndian.PutUint32(d[4:], 0xffffffff) }), items},
+		{"huge node count", modified(func(d []byte) { binary.LittleEndian.PutUint32(d[8:], 0xffffffff) }), items},
+		{"backward child", modified(func(d []byte) { binary.LittleEndian.PutUint32(d[12+32:], 0) }), items},
+		{"leaf past the items", modified(func(d []byte) {
+			// The last node is a leaf
+			leaf := 12 + (nodeCount-1)*bvhNodeSize
+			binary.LittleEndian.PutUint32(d[leaf+32:], 0xffffffff)
+		}), items},
+		{"item out of range", modified(func(d []byte) { binary.LittleEndian.PutUint32(d[orderOffset:], 100+10) }), items},
+		{"duplicate item", modified(func(d []byte) { copy(d[orderOffset:], d[orderOffset+4:orderOffset+8]) }), items},
+		{"shared child", modified(func(d []byte) {
+			// The first child of the root shares the second child of the root
+			copy(d[12+bvhNodeSize+32:], rootIndex)
+		}), items},
+		{"parent smaller than child", modified(func(d []byte) {
+			// Move the maximum x of the root onto its minimum x
+			copy(d[12+16:12+24], d[12:12+8])
+		}), items},
+		{"NaN bounds", modified(func(d []byte) {
+			binary.LittleEndian.PutUint64(d[12:], math.Float64bits(math.NaN()))
+		}), items},
+		{"leaves out of order", modified(func(d []byte) {
+			// Swap the item ranges of the first and last leaves
+			first, last := leaves[0], leaves[len(leaves)-1]
+			var tmp [8]byte
+			copy(tmp[:], d[first+32:first+40])
+			copy(d[first+32:first+40], d[last+32:last+40])
+			copy(d[last+32:last+40], tmp[:])
+		}), items},
+		{"different items", data, reversed},
+	}
+	for _, test := range tests {
+		if _, err := LoadBVH(test.data, test.items); err == nil {
+			t.Errorf("%s: got no error", test.name)
+		}
+	}
+}
+
+func BenchmarkStatic(b *testing.B) {
+	// Compare a BVH with a DynamicTree over static geometry
+	r := rand.New(rand.NewSource(1))
+	box := &Box{Extents: Point{1, 1}}
+	items := make([]BVHItem, 20000)
+	for i := range items {
+		p := Point{r.Float64() * 1000, r.Float64() * 1000}
+		items[i] = BVHItem{Shape: box, Transform: NewTransform(p, r.Float64()*6)}
+	}
+	rays := make([]RayCastInput, 100)
+	for i := range rays {
+		rays[i] = RayCastInput{
+			Origin:      Point{r.Float64() * 1000, r.Float64() * 1000},
+			Direction:   Point{r.Float64()*2 - 1, r.Float64()*2 - 1},
+			MaxFraction: 200,
+		}
+	}
+	newTree := func() *DynamicTree {
+		tree := NewDynamicTree(nil)
+		for i := range items {
+			tree.CreateProxy(ComputeAABB(items[i].Shape, items[i].Transform), i)
+		}
+		return tree
+	}
+
+	b.Run("BVH/build", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			NewBVH(items)
+		}
+	})
+	b.Run("Tree/build", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			newTree()
+		}
+	})
+	b.Run("BVH/raycast", func(b *testing.B) {
+		bvh := NewBVH(items)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for j := range rays {
+				bvh.RayCast(&rays[j])
+			}
+		}
+	})
+	b.Run("Tree/raycast", func(b *testing.B) {
+		tree := newTree()
+		callback := func(input RayCastInput, id int) float64 {
+			item := &items[tree.UserData(id).(int)]
+			output := RayCast(item.Shape, item.Transform, &input)
+			if !output.Hit {
+				return -1
+			}
+			return output.Fraction
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for j := range rays {
+				tree.RayCast(&rays[j], callback)
+			}
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package collide
+
+import (
+	"math"
+)
+
+// ShapeCastInput is the input to ShapeCast.
+type ShapeCastInput struct {
+	A            Shape
+	B            Shape
+	TransformA   Transform
+	TransformB   Transform
+	TranslationB Point     // translation of shape B
+	Settings     *Settings // settings, or nil for MeterSettings
+}
+
+// ShapeCastOutput is the output of ShapeCast.
+type ShapeCastOutput struct {
+	Point      Point   // contact point on shape A in world space
+	Normal     Point   // contact normal from A to B
+	Fraction   float64 // fraction of the translation at the first contact
+	Iterations int     // number of GJK iterations
+	Hit        bool    // whether the shapes come into contact
+	Overlapped bool    // whether the cores of the shapes initially overlap
+}
+
+// ShapeCast computes the first contact of shape B moving along TranslationB
+// with shape A using the GJK raycast algorithm.
+//
+// If the shapes are initially within the target separation, the output
+// reports a hit at fraction zero, with the normal and point of their closest
+// features. If their cores initially overlap, Overlapped is also set and the
+// normal and point are those of the penetration.
+func ShapeCast(input *ShapeCastInput) ShapeCastOutput {
+	settings := settingsOrDefault(input.Settings)
+	linearSlop := settings.LinearSlop
+	tolerance := 0.5 * linearSlop
+	maxIterations := settings.CastMaxIterations
+
+	a, xfa := input.A, input.TransformA
+	b, xfb := input.B, input.TransformB
+	r := input.TranslationB
+
+	radiusA, radiusB := a.GetRadius(), b.GetRadius()
+	radius := radiusA + radiusB
+
+	// Sigma is the target distance between the cores.
+	sigma := math.Max(linearSlop, radius-linearSlop)
+
+	var simplex Simplex
+	var normal Point
+	var lambda float64
+
+	// Get support point in -r direction
+	indexA := a.GetSupport(xfa.Rotation.MulT(r.Neg()))
+	pointA := xfa.Mul(a.GetVertex(indexA))
+	indexB := b.GetSupport(xfb.Rotation.MulT(r))
+	pointB := xfb.Mul(b.GetVertex(indexB))
+	v := pointA.Sub(pointB)
+
+	// Main iteration loop.
+	var iterations int
+	for iterations < maxIterations && v.Length()-sigma > tolerance {
+		// Support in direction -v (A - B)
+		indexA = a.GetSupport(xfa.Rotation.MulT(v.Neg()))
+		pointA = xfa.Mul(a.GetVertex(indexA))
+		indexB = b.GetSupport(xfb.Rotation.MulT(v))
+		pointB = xfb.Mul(b.GetVertex(indexB))
+		p := pointA.Sub(pointB)
+
+		// -v is a normal at p
+		v = v.Normalize()
+
+		// Intersect ray with plane
+		vp := Dot(v, p)
+		vr := Dot(v, r)
+		if vp-sigma > lambda*vr {
+			if vr <= 0 {
+				// Miss
+				return ShapeCastOutput{Iterations: iterations}
+			}
+
+			lambda = (vp - sigma) / vr
+			if lambda > 1 {
+				// Miss
+				return ShapeCastOutput{Iterations: iterations}
+			}
+
+			normal = v.Neg()
+			simplex.count = 0
+		}
+
+		// Reverse simplex since it works with B - A.
+		// Shift by lambda * r because we want the closest point to the current clip point.
+		// Note that the support point p is not shifted because we want the plane equation
+		// to be formed in unshifted space.
+		shifted := pointB.Add(r.Mul(lambda))
+		simplex.v[simplex.count] = vertex{
+			a:      shifted,
+			b:      pointA,
+			p:      pointA.Sub(shifted),
+			u:      1,
+			indexA: indexB,
+			indexB: indexA,
+		}
+		simplex.count++
+		simplex.evolve()
+
+		// If we have 3 points, then the origin is in the corresponding triangle.
+		if simplex.count == 3 {
+			if lambda == 0 {
+				break
+			}
+			// Overlap
+			return ShapeCastOutput{Iterations: iterations}
+		}
+
+		// Get search direction.
+		v = simplex.ClosestPoint()
+
+		// Iteration count is equated to the number of support point calls.
+		iterations++
+	}
+
+	if lambda == 0 {
+		// Initially touching or overlapped
+		point, normal, overlapped := castOverlap(a, xfa, b, xfb)
+		return ShapeCastOutput{
+			Point:      point,
+			Normal:     normal,
+			Iterations: iterations,
+			Hit:        true,
+			Overlapped: overlapped,
+		}
+	}
+
+	// Prepare output
+	_, pointA = simplex.WitnessPoints()
+	if v.LengthSquared() > 0 {
+		normal = v.Neg().Normalize()
+	}
+	return ShapeCastOutput{
+		Point:      pointA.Add(normal.Mul(radiusA)),
+		Normal:     normal,
+		Fraction:   lambda,
+		Iterations: iterations,
+		Hit:        true,
+	}
+}
+
+// castOverlap returns the contact point on shape A and the contact normal
+// for two shapes that start within the target separation, and whether their
+// cores overlap.
+func castOverlap(a Shape, xfa Transform, b Shape, xfb Transform) (Point, Point, bool) {
+	var simplex Simplex
+	simplex.GJK(a, xfa, b, xfb)
+	pointA, _, distance := simplex.closestPoints(xfa, xfb, 0, 0)
+	if distance > 0 {
+		normal := simplex.ClosestPoint().Normalize()
+		return pointA.Add(normal.Mul(a.GetRadius())), normal, false
+	}
+
+	penetration := simplex.EPA(a, xfa, b, xfb)
+	return penetration.PointA, penetration.Normal, true
+}
@@ -0,0 +1,180 @@
+package collide
+
+import (
+	"math"
+	"testing"
+)
+
+// sweepCircles returns the fraction of the translation r at which a point
+// starting at p first comes within distance d of the origin, or -1 if it
+// never does.
+func sweepCircles(p, r Point, d float64) float64 {
+	// Solve |p + t*r| = d for the smallest t
+	a := Dot(r, r)
+	b := 2 * Dot(p, r)
+	c := Dot(p, p) - d*d
+	disc := b*b - 4*a*c
+	if disc < 0 {
+		return -1
+	}
+	t := (-b - math.Sqrt(disc)) / (2 * a)
+	if t < 0 || t > 1 {
+		return -1
+	}
+	return t
+}
+
+func TestShapeCastCircles(t *testing.T) {
+	slop := MeterSettings().LinearSlop
+	tests := []struct {
+		name           string
+		radiusA        float64
+		radiusB        float64
+		position       Point
+		translation    Point
+		hit            bool
+		fractionIsZero bool
+	}{
+		{"head on", 1, 0.5, Point{5, 0}, Point{-4, 0}, true, false},
+		{"offset", 1, 0.5, Point{5, 1}, Point{-6, 0}, true, false},
+		{"diagonal", 0.25, 0.75, Point{-3, -4}, Point{6, 8}, true, false},
+		{"grazing", 1, 1, Point{4, 1.9}, Point{-8, 0}, true, false},
+		{"short", 1, 0.5, Point{5, 0}, Point{-2, 0}, false, false},
+		{"parallel", 1, 0.5, Point{5, 2}, Point{-10, 0}, false, false},
+		{"away", 1, 0.5, Point{5, 0}, Point{4, 0}, false, false},
+	}
+	for _, test := range tests {
+		input := ShapeCastInput{
+			A:            &Circle{Radius: test.radiusA},
+			B:            &Circle{Radius: test.radiusB},
+			TransformA:   NewTransform(Point{}, 0),
+			TransformB:   NewTransform(test.position, 0),
+			TranslationB: test.translation,
+		}
+		output := ShapeCast(&input)
+		if output.Hit != test.hit {
+			t.Errorf("%s: got hit %v, want %v", test.name, output.Hit, test.hit)
+			continue
+		}
+		if !output.Hit {
+			continue
+		}
+		if output.Overlapped {
+			t.Errorf("%s: got overlapped", test.name)
+		}
+
+		// The cast stops within LinearSlop of the target separation
+		radius := test.radiusA + test.radiusB
+		want := sweepCircles(test.position, test.translation, radius-slop)
+		length := test.translation.Length()
+		if math.Abs(output.Fraction-want)*length > slop {
+			t.Errorf("%s: got fraction %v, want %v", test.name, output.Fraction, want)
+		}
+
+		// The normal points from A to B at the time of impact
+		center := test.position.Add(test.translation.Mul(output.Fraction))
+		normal := center.Normalize()
+		if Dot(output.Normal, normal) < 1-1e-6 {
+			t.Errorf("%s: got normal %v, want %v", test.name, output.Normal, normal)
+		}
+		point := normal.Mul(test.radiusA)
+		if output.Point.Sub(point).Length() > slop {
+			t.Errorf("%s: got point %v, want %v", test.name, output.Point, point)
+		}
+	}
+}
+
+func TestShapeCastInitialContact(t *testing.T) {
+	slop := MeterSettings().LinearSlop
+	square := Rectangle(Point{}, Point{1, 1})
+	tests := []struct {
+		name       string
+		a          Shape
+		b          Shape
+		position   Point
+		overlapped bool
+		normal     Point
+	}{
+		{
+			name:     "polygons within slop",
+			a:        square,
+			b:        square,
+			position: Point{2 + slop/2, 0},
+			normal:   Point{1, 0},
+		},
+		{
+			name:       "overlapping polygons",
+			a:          square,
+			b:          square,
+			position:   Point{1.5, 0.25},
+			overlapped: true,
+			normal:     Point{1, 0},
+		},
+		{
+			name:     "overlapping circles",
+			a:        &Circle{Radius: 1},
+			b:        &Circle{Radius: 1},
+			position: Point{0, 1.5},
+			normal:   Point{0, 1},
+		},
+		{
+			name:     "circle inside polygon rounding",
+			a:        square,
+			b:        &Circle{Radius: 0.5},
+			position: Point{1.25, 0},
+			normal:   Point{1, 0},
+		},
+		{
+			name:       "circle center inside polygon",
+			a:          square,
+			b:          &Circle{Radius: 0.5},
+			position:   Point{0.75, 0},
+			overlapped: true,
+			normal:     Point{1, 0},
+		},
+	}
+	for _, test := range tests {
+		output := ShapeCast(&ShapeCastInput{
+			A:            test.a,
+			B:            test.b,
+			TransformA:   NewTransform(Point{}, 0),
+			TransformB:   NewTransform(test.position, 0),
+			TranslationB: Point{1, 0},
+		})
+		if !output.Hit || output.Fraction != 0 {
+			t.Errorf("%s: got hit %v at %v, want hit at 0", test.name, output.Hit, output.Fraction)
+		}
+		if output.Overlapped != test.overlapped {
+			t.Errorf("%s: got overlapped %v, want %v", test.name, output.Overlapped, test.overlapped)
+		}
+		if !approxEqualPoint(output.Normal, test.normal) {
+			t.Errorf("%s: got normal %v, want %v", test.name, output.Normal, test.normal)
+		}
+	}
+}
+
+func TestShapeCastPolygon(t *testing.T) {
+	slop := MeterSettings().LinearSlop
+	output := ShapeCast(&ShapeCastInput{
+		A:            Rectangle(Point{}, Point{1, 1}),
+		B:            Rectangle(Point{}, Point{0.5, 0.5}),
+		TransformA:   NewTransform(Point{}, 0),
+		TransformB:   NewTransform(Point{0.25, 5}, 0),
+		TranslationB: Point{0, -8},
+	})
+	if !output.Hit || output.Overlapped {
+		t.Fatalf("got hit %v, overlapped %v, want a hit", output.Hit, output.Overlapped)
+	}
+
+	// The faces meet after moving 3.5, and the cast stops within
+	// LinearSlop of touching
+	if d := math.Abs(output.Fraction*8 - 3.5); d > slop {
+		t.Errorf("got fraction %v, want %v", output.Fraction, 3.5/8)
+	}
+	if !approxEqualPoint(output.Normal, Point{0, 1}) {
+		t.Errorf("got normal %v, want (0, 1)", output.Normal)
+	}
+	if math.Abs(output.Point.Y-1) > slop {
+		t.Errorf("got point %v on A, want it on the top face", output.Point)
+	}
+}
@@ -21,45 +21,157 @@ package collide
 
 import (
 	"math"
+	"sync"
 )
 
 // Collision represents a collision.
 type Collision struct {
-	Normal Point
-	Depth  float64
+	Normal Point           // collision normal from A to B
+	Depth  float64         // maximum penetration depth, negative if speculative
+	Points [2]ContactPoint // contact points
+	Count  int             // number of contact points
 }
 
-// Collide calculates a collision for two shapes.
+// ContactPoint represents a contact point of a collision.
+type ContactPoint struct {
+	Point      Point   // midpoint between the surfaces in world space
+	Separation float64 // negative if the shapes overlap at this point
+}
+
+// flip flips the collision so that it is from B to A.
+func (c *Collision) flip() {
+	c.Normal = c.Normal.Neg()
+}
+
+// clone returns a copy of the collision allocated on the heap.
+func (c *Collision) clone() *Collision {
+	clone := *c
+	return &clone
+}
+
+// addPoint adds a contact point to the collision and updates its depth.
+func (c *Collision) addPoint(point Point, separation float64) {
+	if c.Count == 0 || -separation > c.Depth {
+		c.Depth = -separation
+	}
+	c.Points[c.Count] = ContactPoint{
+		Point:      point,
+		Separation: separation,
+	}
+	c.Count++
+}
+
+// collisionPool holds the collisions returned by Collide, so that no memory
+// is allocated for shapes that do not collide.
+var collisionPool = sync.Pool{
+	New: func() interface{} {
+		return new(Collision)
+	},
+}
+
+// Collide calculates a collision for two shapes. It uses the collider
+// registered for the types of the shapes, and falls back to GJK and EPA
+// for shapes without one. It returns nil if the shapes do not overlap.
 func Collide(a Shape, xfa Transform, b Shape, xfb Transform) *Collision {
-	switch a := a.(type) {
-	case *Circle:
-		switch b := b.(type) {
-		case *Circle:
-			return CollideCircles(a, xfa, b, xfb)
-		case *Polygon:
-			return CollideCircleAndPolygon(a, xfa, b, xfb)
+	return CollideSpeculative(a, xfa, b, xfb, 0)
+}
+
+// CollideSpeculative is like Collide, but also returns speculative contacts
+// for shapes that are separated by less than the given margin. The contact
+// points of such collisions have a positive separation.
+func CollideSpeculative(a Shape, xfa Transform, b Shape, xfb Transform, margin float64) *Collision {
+	c := collisionPool.Get().(*Collision)
+	if !CollideSpeculativeTo(c, a, xfa, b, xfb, margin) {
+		collisionPool.Put(c)
+		return nil
+	}
+	return c
+}
+
+// CollideTo is like Collide, but stores the collision in c instead of
+// allocating it. It reports whether the shapes overlap. If they do not,
+// c is reset to the zero Collision.
+func CollideTo(c *Collision, a Shape, xfa Transform, b Shape, xfb Transform) bool {
+	return CollideSpeculativeTo(c, a, xfa, b, xfb, 0)
+}
+
+// CollideSpeculativeTo is like CollideSpeculative, but stores the collision
+// in c instead of allocating it. It reports whether a collision was found.
+// If not, c is reset to the zero Collision.
+func CollideSpeculativeTo(c *Collision, a Shape, xfa Transform, b Shape, xfb Transform, margin float64) bool {
+	var ok bool
+	if fn, flip := lookupCollider(a, b); fn == nil {
+		ok = collideConvex(c, a, xfa, b, xfb, margin)
+	} else if !flip {
+		ok = fn(c, a, xfa, b, xfb, margin)
+	} else if ok = fn(c, b, xfb, a, xfa, margin); ok {
+		c.flip()
+	}
+	if !ok {
+		*c = Collision{}
+	}
+	return ok
+}
+
+// collideConvex calculates a collision for two arbitrary convex shapes
+// using GJK and EPA.
+func collideConvex(c *Collision, a Shape, xfa Transform, b Shape, xfb Transform, margin float64) bool {
+	radiusA, radiusB := a.GetRadius(), b.GetRadius()
+
+	var simplex Simplex
+	simplex.GJK(a, xfa, b, xfb)
+	if simplex.count < 3 {
+		// The cores are separated or touching
+		pointA, pointB, distance := simplex.closestPoints(xfa, xfb, 0, 0)
+		separation := distance - radiusA - radiusB
+		if separation >= margin {
+			return false
 		}
-	case *Polygon:
-		switch b := b.(type) {
-		case *Circle:
-			return CollidePolygonAndCircle(a, xfa, b, xfb)
-		case *Polygon:
-			return CollidePolygons(a, xfa, b, xfb)
+		if distance > 0 {
+			normal := pointB.Sub(pointA).Div(distance)
+			pointA = pointA.Add(normal.Mul(radiusA))
+			pointB = pointB.Sub(normal.Mul(radiusB))
+
+			*c = Collision{Normal: normal}
+			c.addPoint(pointA.Add(pointB).Mul(0.5), separation)
+			return true
 		}
 	}
-	return nil
+
+	penetration := simplex.EPA(a, xfa, b, xfb)
+	separation := -penetration.Depth - radiusA - radiusB
+	if separation >= margin {
+		return false
+	}
+	normal := penetration.Normal
+	pointA := penetration.PointA.Add(normal.Mul(radiusA))
+	pointB := penetration.PointB.Sub(normal.Mul(radiusB))
+
+	*c = Collision{Normal: normal}
+	c.addPoint(pointA.Add(pointB).Mul(0.5), separation)
+	return true
 }
 
 func CollideCircles(a *Circle, xfa Transform, b *Circle, xfb Transform) *Collision {
-	centerA, centerB := xfa.Position.Add(a.Center), xfb.Position.Add(b.Center)
+	var c Collision
+	if !collideCircles(&c, a, xfa, b, xfb, 0) {
+		return nil
+	}
+	return c.clone()
+}
+
+func collideCircles(c *Collision, a *Circle, xfa Transform, b *Circle, xfb Transform, margin float64) bool {
+	centerA, centerB := xfa.Mul(a.Center), xfb.Mul(b.Center)
 	n := centerB.Sub(centerA)
 	r := a.Radius + b.Radius
 
 	d := n.LengthSquared()
-	if d > r*r {
-		return nil
+	if d >= (r+margin)*(r+margin) {
+		return false
 	}
 
+	d = math.Sqrt(d)
+
 	if d != 0 {
 		n = n.Div(d)
 	} else {
@@ -68,102 +180,56 @@ func CollideCircles(a *Circle, xfa Transform, b *Circle, xfb Transform) *Collisi
 		n = Point{1, 0}
 	}
 
-	d = math.Sqrt(d)
+	// Contact point is midway between the surfaces
+	pointA := centerA.Add(n.Mul(a.Radius))
+	pointB := centerB.Sub(n.Mul(b.Radius))
 
-	return &Collision{
-		Normal: n,
-		Depth:  d - r,
-	}
+	*c = Collision{Normal: n}
+	c.addPoint(pointA.Add(pointB).Mul(0.5), d-r)
+	return true
 }
 
 func CollidePolygonAndCircle(a *Polygon, xfa Transform, b *Circle, xfb Transform) *Collision {
-	// Compute circle position in the frame of the polygon
-	center := xfa.MulT(b.Center.Add(xfb.Position))
-
-	// Find edge with minimum penetration
-	var normalIndex int
-	separation := -math.MaxFloat64
-	for i := range a.Points {
-		s := Dot(a.Normals[i], center.Sub(a.Points[i]))
-
-		if s > b.Radius {
-			// Early out
-			return nil
-		}
-
-		if s > separation {
-			separation = s
-			normalIndex = i
-		}
+	var c Collision
+	if !collidePolygonAndCircle(&c, a, xfa, b, xfb, 0) {
+		return nil
 	}
+	return c.clone()
+}
 
-	// Grab face's vertices
-	i := normalIndex
-	j := i + 1
-	if j == len(a.Points) {
-		j = 0
-	}
-	v1, v2 := a.Points[i], a.Points[j]
+func collidePolygonAndCircle(c *Collision, a *Polygon, xfa Transform, b *Circle, xfb Transform, margin float64) bool {
+	// Compute circle position in the frame of the polygon
+	center := xfa.MulT(xfb.Mul(b.Center))
 
-	// If the center is inside the polygon
-	if separation == 0 {
-		normal := xfa.Rotation.Mul(a.Normals[normalIndex]).Neg()
-		return &Collision{
-			Normal: normal,
-			Depth:  b.Radius,
-		}
+	// Find the closest feature of the polygon
+	feature, point, separation := a.closestFeature(center)
+	if separation >= b.Radius+margin {
+		// Early out
+		return false
 	}
 
-	// Compute barycentric coordinates
-	u1 := Dot(center.Sub(v1), v2.Sub(v1))
-	u2 := Dot(center.Sub(v2), v1.Sub(v2))
-	if u1 <= 0 {
-		// Closest to v1
-		d := center.Sub(v1)
-		if Dot(d, d) > b.Radius*b.Radius {
-			return nil
-		}
-
-		n := v1.Sub(center)
-		n = xfa.Rotation.Mul(n).Normalize()
-		return &Collision{
-			Normal: n,
-			Depth:  b.Radius - separation,
-		}
-	} else if u2 <= 0 {
-		// Closest to v2
-		n := center.Sub(v2)
-		if Dot(n, n) > b.Radius*b.Radius {
-			return nil
-		}
-		n = xfa.Rotation.Mul(n).Normalize()
-		return &Collision{
-			Normal: n,
-			Depth:  b.Radius - separation,
-		}
-
+	var n Point
+	if feature.Type == FeatureVertex {
+		// Closest to a vertex
+		n = center.Sub(point).Normalize()
 	} else {
-		// Closest to face
-		n := a.Normals[normalIndex]
-		face := v1.Add(v2).Mul(0.5)
-		if Dot(center.Sub(face), n) > b.Radius {
-			return nil
-		}
-
-		n = xfa.Rotation.Mul(n)
-		return &Collision{
-			Normal: n,
-			Depth:  b.Radius - separation,
-		}
+		// Closest to a face or the center is inside the polygon
+		n = a.Normals[feature.Index]
 	}
 
-	return nil
+	// Contact point is midway between the surfaces
+	pointB := center.Sub(n.Mul(b.Radius))
+	contact := xfa.Mul(point.Add(pointB).Mul(0.5))
+
+	*c = Collision{Normal: xfa.Rotation.Mul(n)}
+	c.addPoint(contact, separation-b.Radius)
+	return true
 }
 
 func CollideCircleAndPolygon(a *Circle, xfa Transform, b *Polygon, xfb Transform) *Collision {
 	collision := CollidePolygonAndCircle(b, xfb, a, xfa)
 	if collision != nil {
-		collision.Normal = collision.Normal.Neg()
+		collision.flip()
 	}
 	return collision
 }
@@ -262,18 +328,83 @@ func clip(n Point, c float64, edge []Point) int {
 }
 
 func CollidePolygons(a *Polygon, xfa Transform, b *Polygon, xfb Transform) *Collision {
+	var c Collision
+	if !collidePolygons(&c, a, xfa, b, xfb, 0) {
+		return nil
+	}
+	return c.clone()
+}
+
+func collidePolygons(c *Collision, a *Polygon, xfa Transform, b *Polygon, xfb Transform, margin float64) bool {
 	// Check for a separating axis with A's edges
 	edgeA, separationA := findMaxSeparation(a, xfa, b, xfb)
-	if separationA >= 0 {
-		return nil
+	if separationA >= margin {
+		return false
 	}
 
 	// Check for a separating axis with B's edges
 	edgeB, separationB := findMaxSeparation(b, xfb, a, xfa)
-	if separationB >= 0 {
-		return nil
+	if separationB >= margin {
+		return false
+	}
+
+	return clipPolygons(c, a, xfa, b, xfb, edgeA, separationA, edgeB, separationB, margin)
+}
+
+// Side identifies one of the two shapes of a pair.
+type Side int
+
+const (
+	SideA Side = iota // the first shape
+	SideB             // the second shape
+)
+
+// PolygonReport describes either the collision of two polygons or the axis
+// that separates them.
+type PolygonReport struct {
+	Collision  *Collision // collision, or nil if the polygons are separated
+	Axis       Point      // axis of maximum separation from A to B in world space
+	Separation float64    // separation along the axis, negative if overlapping
+	Reference  Side       // shape whose face supplies the axis
+	Face       int        // index of the face supplying the axis
+}
+
+// ReportPolygons is like CollidePolygons, but also reports the axis of
+// maximum separation, whether or not the polygons collide.
+func ReportPolygons(a *Polygon, xfa Transform, b *Polygon, xfb Transform, margin float64) PolygonReport {
+	edgeA, separationA := findMaxSeparation(a, xfa, b, xfb)
+	edgeB, separationB := findMaxSeparation(b, xfb, a, xfa)
+
+	var report PolygonReport
+	if separationB > separationA {
+		report = PolygonReport{
+			Axis:       xfb.Rotation.Mul(b.Normals[edgeB]).Neg(),
+			Separation: separationB,
+			Reference:  SideB,
+			Face:       edgeB,
+		}
+	} else {
+		report = PolygonReport{
+			Axis:       xfa.Rotation.Mul(a.Normals[edgeA]),
+			Separation: separationA,
+			Reference:  SideA,
+			Face:       edgeA,
+		}
+	}
+
+	if report.Separation < margin {
+		var c Collision
+		if clipPolygons(&c, a, xfa, b, xfb, edgeA, separationA, edgeB, separationB, margin) {
+			report.Collision = c.clone()
+		}
 	}
+	return report
+}
 
+// clipPolygons calculates the collision of two polygons given their axes of
+// maximum separation.
+func clipPolygons(c *Collision, a *Polygon, xfa Transform, b *Polygon, xfb Transform,
+	edgeA int, separationA float64, edgeB int, separationB float64, margin float64) bool {
 	var edge int  // reference edge
 	var flip bool // Always point from a to b
 
@@ -324,36 +455,285 @@ func CollidePolygons(a *Polygon, xfa Transform, b *Polygon, xfb Transform) *Coll
 	posSide := Dot(tangent, v2)
 
 	// Clip incident face to reference face side planes
-	if clip(tangent.Neg(), negSide, incidentEdge[:]) < 2 {
-		// Due to floating point error, possible to not have required points
-		return nil
-	}
-	if clip(tangent, posSide, incidentEdge[:]) < 2 {
-		// Due to floating point error, possible to not have required points
-		return nil
+	incident := incidentEdge
+	if clip(tangent.Neg(), negSide, incidentEdge[:]) < 2 ||
+		clip(tangent, posSide, incidentEdge[:]) < 2 {
+		// The incident face lies beside the reference face, which happens
+		// for speculative contacts between vertices, or due to floating
+		// point error. Fall back to a single contact point between the
+		// closest features.
+		return collideEdges(c, v1, v2, incident, flip, margin)
 	}
 
-	var overlap float64
-	separation0 := Dot(normal, incidentEdge[0]) - refC
-	if separation0 <= 0 {
-		overlap = -separation0
+	// Keep points within the margin. The contact point is midway between
+	// the incident point and the reference face.
+	*c = Collision{}
+	for _, p := range incidentEdge {
+		separation := Dot(normal, p) - refC
+		if separation < margin {
+			c.addPoint(p.Sub(normal.Mul(0.5*separation)), separation)
+		}
 	}
-	separation1 := Dot(normal, incidentEdge[1]) - refC
-	if separation1 <= 0 {
-		// Maximum penetration
-		overlap = math.Max(overlap, -separation1)
+
+	if c.Count == 0 {
+		return false
 	}
 
 	// Flip normal
 	if flip {
 		normal = normal.Neg()
 	}
+	c.Normal = normal
+	return true
+}
+
+// collideEdges calculates a collision with a single contact point between
+// the closest points of the reference edge v1-v2 and the incident edge.
+func collideEdges(c *Collision, v1, v2 Point, incident [2]Point, flip bool, margin float64) bool {
+	pointA, pointB := closestPointsOnSegments(v1, v2, incident[0], incident[1])
+	d := pointB.Sub(pointA)
+	separation := d.Length()
+	if separation >= margin || separation == 0 {
+		return false
+	}
+
+	normal := d.Div(separation)
+	if flip {
+		normal = normal.Neg()
+	}
+	*c = Collision{Normal: normal}
+	c.addPoint(pointA.Add(pointB).Mul(0.5), separation)
+	return true
+}
+
+// closestPointsOnSegments returns the closest points of the segments p1-q1
+// and p2-q2.
+func closestPointsOnSegments(p1, q1, p2, q2 Point) (Point, Point) {
+	d1, d2 := q1.Sub(p1), q2.Sub(p2)
+	r := p1.Sub(p2)
+	a, e := Dot(d1, d1), Dot(d2, d2)
+	b, c, f := Dot(d1, d2), Dot(d1, r), Dot(d2, r)
+	clamp := func(x float64) float64 {
+		return math.Max(0, math.Min(1, x))
+	}
+
+	// Find the parameters s and t of the closest points on each segment
+	var s, t float64
+	switch {
+	case a == 0 && e == 0:
+		// Both segments are points
+	case a == 0:
+		t = clamp(f / e)
+	case e == 0:
+		s = clamp(-c / a)
+	default:
+		if denom := a*e - b*b; denom != 0 {
+			s = clamp((b*f - c*e) / denom)
+		}
+		t = (b*s + f) / e
+		if t < 0 {
+			t = 0
+			s = clamp(-c / a)
+		} else if t > 1 {
+			t = 1
+			s = clamp((b - c) / a)
+		}
+	}
+	return p1.Add(d1.Mul(s)), p2.Add(d2.Mul(t))
+}
+
+func CollideBoxes(a *Box, xfa Transform, b *Box, xfb Transform) *Collision {
+	var c Collision
+	if !collideBoxes(&c, a, xfa, b, xfb, 0) {
+		return nil
+	}
+	return c.clone()
+}
+
+func collideBoxes(c *Collision, a *Box, xfa Transform, b *Box, xfb Transform, margin float64) bool {
+	if !xfa.Rotation.isIdentity() || !xfb.Rotation.isIdentity() {
+		var pointsA, pointsB [4]Point
+		polyA, polyB := a.polygon(&pointsA), b.polygon(&pointsB)
+		return collidePolygons(c, &polyA, xfa, &polyB, xfb, margin)
+	}
+
+	centerA := xfa.Position.Add(a.Center)
+	centerB := xfb.Position.Add(b.Center)
+	d := centerB.Sub(centerA)
+
+	// Find the separation along each axis
+	separationX := math.Abs(d.X) - a.Extents.X - b.Extents.X
+	separationY := math.Abs(d.Y) - a.Extents.Y - b.Extents.Y
+	if separationX >= margin || separationY >= margin {
+		// Early out
+		return false
+	}
+
+	sign := Point{math.Copysign(1, d.X), math.Copysign(1, d.Y)}
+	if separationX > 0 && separationY > 0 {
+		// The faces do not overlap, so the closest features are corners.
+		// Use a single contact point between them.
+		separation := math.Hypot(separationX, separationY)
+		if separation >= margin {
+			return false
+		}
+		cornerA := centerA.Add(Point{sign.X * a.Extents.X, sign.Y * a.Extents.Y})
+		cornerB := centerB.Sub(Point{sign.X * b.Extents.X, sign.Y * b.Extents.Y})
+		*c = Collision{Normal: cornerB.Sub(cornerA).Div(separation)}
+		c.addPoint(cornerA.Add(cornerB).Mul(0.5), separation)
+		return true
+	}
+
+	// The contact points span the overlap of the faces along the axis of
+	// maximum separation, midway between the faces.
+	*c = Collision{}
+	if separationX > separationY {
+		c.Normal = Point{sign.X, 0}
+		x := centerA.X + sign.X*(a.Extents.X+0.5*separationX)
+		lower := math.Max(centerA.Y-a.Extents.Y, centerB.Y-b.Extents.Y)
+		upper := math.Min(centerA.Y+a.Extents.Y, centerB.Y+b.Extents.Y)
+		c.addPoint(Point{x, lower}, separationX)
+		c.addPoint(Point{x, upper}, separationX)
+	} else {
+		c.Normal = Point{0, sign.Y}
+		y := centerA.Y + sign.Y*(a.Extents.Y+0.5*separationY)
+		lower := math.Max(centerA.X-a.Extents.X, centerB.X-b.Extents.X)
+		upper := math.Min(centerA.X+a.Extents.X, centerB.X+b.Extents.X)
+		c.addPoint(Point{lower, y}, separationY)
+		c.addPoint(Point{upper, y}, separationY)
+	}
+	return true
+}
 
-	if overlap == 0 {
+func CollideBoxAndCircle(a *Box, xfa Transform, b *Circle, xfb Transform) *Collision {
+	var c Collision
+	if !collideBoxAndCircle(&c, a, xfa, b, xfb, 0) {
 		return nil
 	}
-	return &Collision{
-		Normal: normal,
-		Depth:  overlap,
+	return c.clone()
+}
+
+func collideBoxAndCircle(c *Collision, a *Box, xfa Transform, b *Circle, xfb Transform, margin float64) bool {
+	if !xfa.Rotation.isIdentity() {
+		var points [4]Point
+		poly := a.polygon(&points)
+		return collidePolygonAndCircle(c, &poly, xfa, b, xfb, margin)
+	}
+
+	// Compute circle position relative to the center of the box
+	centerA := xfa.Position.Add(a.Center)
+	center := xfb.Mul(b.Center)
+	d := center.Sub(centerA)
+
+	// Find the closest point on the box
+	e := a.Extents
+	point := Point{
+		math.Max(-e.X, math.Min(e.X, d.X)),
+		math.Max(-e.Y, math.Min(e.Y, d.Y)),
+	}
+
+	var n Point
+	var separation float64
+	if point == d {
+		// The center is inside the box, use the face of minimum penetration
+		dx := e.X - math.Abs(d.X)
+		dy := e.Y - math.Abs(d.Y)
+		if dx < dy {
+			n = Point{math.Copysign(1, d.X), 0}
+			point.X = n.X * e.X
+			separation = -dx
+		} else {
+			n = Point{0, math.Copysign(1, d.Y)}
+			point.Y = n.Y * e.Y
+			separation = -dy
+		}
+	} else {
+		n = d.Sub(point)
+		separation = n.Length()
+		n = n.Div(separation)
+	}
+
+	if separation >= b.Radius+margin {
+		// Early out
+		return false
+	}
+
+	// Contact point is midway between the surfaces
+	pointA := centerA.Add(point)
+	pointB := center.Sub(n.Mul(b.Radius))
+
+	*c = Collision{Normal: n}
+	c.addPoint(pointA.Add(pointB).Mul(0.5), separation-b.Radius)
+	return true
+}
+
+func CollideBoxAndPolygon(a *Box, xfa Transform, b *Polygon, xfb Transform) *Collision {
+	var c Collision
+	if !collideBoxAndPolygon(&c, a, xfa, b, xfb, 0) {
+		return nil
+	}
+	return c.clone()
+}
+
+func collideBoxAndPolygon(c *Collision, a *Box, xfa Transform, b *Polygon, xfb Transform, margin float64) bool {
+	var points [4]Point
+	if !xfa.Rotation.isIdentity() {
+		poly := a.polygon(&points)
+		return collidePolygons(c, &poly, xfa, b, xfb, margin)
+	}
+
+	// Check for a separating axis with the box's edges
+	edgeA, separationA := findBoxSeparation(a, xfa, b, xfb)
+	if separationA >= margin {
+		return false
+	}
+
+	poly := a.polygon(&points)
+
+	// Check for a separating axis with B's edges
+	edgeB, separationB := findMaxSeparation(b, xfb, &poly, xfa)
+	if separationB >= margin {
+		return false
+	}
+
+	return clipPolygons(c, &poly, xfa, b, xfb, edgeA, separationA, edgeB, separationB, margin)
+}
+
+// Find the maximum separation between an unrotated box a and b using the
+// edge normals of a.
+func findBoxSeparation(a *Box, xfa Transform, b *Polygon, xfb Transform) (int, float64) {
+	// Compute the bounds of B relative to the center of A
+	center := xfa.Position.Add(a.Center)
+	lower := Point{math.MaxFloat64, math.MaxFloat64}
+	upper := Point{-math.MaxFloat64, -math.MaxFloat64}
+	for i := range b.Points {
+		p := xfb.Mul(b.Points[i]).Sub(center)
+		if p.X < lower.X {
+			lower.X = p.X
+		}
+		if p.X > upper.X {
+			upper.X = p.X
+		}
+		if p.Y < lower.Y {
+			lower.Y = p.Y
+		}
+		if p.Y > upper.Y {
+			upper.Y = p.Y
+		}
+	}
+
+	// The edges are in the same order as boxNormals
+	separations := [4]float64{
+		-a.Extents.Y - upper.Y,
+		lower.X - a.Extents.X,
+		lower.Y - a.Extents.Y,
+		-a.Extents.X - upper.X,
+	}
+	bestIndex := 0
+	for i := 1; i < len(separations); i++ {
+		if separations[i] > separations[bestIndex] {
+			bestIndex = i
+		}
 	}
+	return bestIndex, separations[bestIndex]
 }
@@ -0,0 +1,284 @@
+package collide
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCollideSpeculative(t *testing.T) {
+	circle := &Circle{Radius: 1}
+	square := Rectangle(Point{}, Point{1, 1})
+	box := &Box{Extents: Point{1, 1}}
+	diagonal := math.Hypot(0.05, 0.05)
+	tests := []struct {
+		name        string
+		a, b        Shape
+		xfb         Transform
+		margin      float64
+		normal      Point
+		separations []float64
+	}{
+		{
+			name:        "overlapping circles",
+			a:           circle,
+			b:           circle,
+			xfb:         NewTransform(Point{0, 1.5}, 0),
+			normal:      Point{0, 1},
+			separations: []float64{-0.5},
+		},
+		{
+			name:        "speculative circles",
+			a:           circle,
+			b:           circle,
+			xfb:         NewTransform(Point{2.1, 0}, 0),
+			margin:      0.2,
+			normal:      Point{1, 0},
+			separations: []float64{0.1},
+		},
+		{
+			name:   "circles beyond the margin",
+			a:      circle,
+			b:      circle,
+			xfb:    NewTransform(Point{2.3, 0}, 0),
+			margin: 0.2,
+		},
+		{
+			name:        "polygon and speculative circle",
+			a:           square,
+			b:           circle,
+			xfb:         NewTransform(Point{0, -2.1}, 0),
+			margin:      0.2,
+			normal:      Point{0, -1},
+			separations: []float64{0.1},
+		},
+		{
+			name:        "overlapping polygons",
+			a:           square,
+			b:           square,
+			xfb:         NewTransform(Point{1.5, 0}, 0),
+			normal:      Point{1, 0},
+			separations: []float64{-0.5, -0.5},
+		},
+		{
+			name:        "speculative polygons",
+			a:           square,
+			b:           square,
+			xfb:         NewTransform(Point{0.5, 2.1}, 0),
+			margin:      0.2,
+			normal:      Point{0, 1},
+			separations: []float64{0.1, 0.1},
+		},
+		{
+			name:        "tilted speculative polygons",
+			a:           square,
+			b:           square,
+			xfb:         NewTransform(Point{0, 2.1}, 0.1),
+			margin:      0.1,
+			normal:      Point{0, 1},
+			separations: []float64{2.1 - math.Cos(0.1) - math.Sin(0.1) - 1},
+		},
+		{
+			name:        "speculative polygon corners",
+			a:           square,
+			b:           square,
+			xfb:         NewTransform(Point{2.05, 2.05}, 0),
+			margin:      0.2,
+			normal:      Point{math.Sqrt2 / 2, math.Sqrt2 / 2},
+			separations: []float64{diagonal},
+		},
+		{
+			name:   "polygon corners beyond the margin",
+			a:      square,
+			b:      square,
+			xfb:    NewTransform(Point{2.05, 2.05}, 0),
+			margin: 0.05,
+		},
+		{
+			name:        "speculative box corners",
+			a:           box,
+			b:           box,
+			xfb:         NewTransform(Point{-2.05, 2.05}, 0),
+			margin:      0.2,
+			normal:      Point{-math.Sqrt2 / 2, math.Sqrt2 / 2},
+			separations: []float64{diagonal},
+		},
+		{
+			name:        "speculative box and polygon corners",
+			a:           box,
+			b:           square,
+			xfb:         NewTransform(Point{2.05, -2.05}, 0),
+			margin:      0.2,
+			normal:      Point{math.Sqrt2 / 2, -math.Sqrt2 / 2},
+			separations: []float64{diagonal},
+		},
+		{
+			name: "polygon corners without a margin",
+			a:    square,
+			b:    square,
+			xfb:  NewTransform(Point{2.05, 2.05}, 0),
+		},
+	}
+	origin := NewTransform(Point{}, 0)
+	for _, test := range tests {
+		c := CollideSpeculative(test.a, origin, test.b, test.xfb, test.margin)
+		if c == nil {
+			if len(test.separations) > 0 {
+				t.Errorf("%s: got no collision", test.name)
+			}
+			continue
+		}
+		if len(test.separations) == 0 {
+			t.Errorf("%s: got collision %v, want none", test.name, *c)
+			continue
+		}
+		if !approxEqualPoint(c.Normal, test.normal) {
+			t.Errorf("%s: got normal %v, want %v", test.name, c.Normal, test.normal)
+		}
+		if c.Count != len(test.separations) {
+			t.Errorf("%s: got %d points, want %d", test.name, c.Count, len(test.separations))
+			continue
+		}
+		for i, separation := range test.separations {
+			if !approxEqual(c.Points[i].Separation, separation) {
+				t.Errorf("%s: got separation %v, want %v", test.name, c.Points[i].Separation, separation)
+			}
+		}
+
+		// The reversed pair has the opposite normal
+		r := CollideSpeculative(test.b, test.xfb, test.a, origin, test.margin)
+		if r == nil || !approxEqualPoint(r.Normal, test.normal.Neg()) {
+			t.Errorf("%s: got reversed collision %v, want normal %v", test.name, r, test.normal.Neg())
+		}
+	}
+}
+
+func TestCollidePairs(t *testing.T) {
+	// The pair functions only report overlapping shapes
+	circle := &Circle{Radius: 1}
+	square := Rectangle(Point{}, Point{1, 1})
+	box := &Box{Extents: Point{1, 1}}
+	origin := NewTransform(Point{}, 0)
+	near := NewTransform(Point{1.5, 0.5}, 0)
+	far := NewTransform(Point{2.01, 0.5}, 0)
+	tests := []struct {
+		name    string
+		collide func(xfb Transform) *Collision
+	}{
+		{"CollideCircles", func(xfb Transform) *Collision {
+			return CollideCircles(circle, origin, circle, xfb)
+		}},
+		{"CollidePolygonAndCircle", func(xfb Transform) *Collision {
+			return CollidePolygonAndCircle(square, origin, circle, xfb)
+		}},
+		{"CollideCircleAndPolygon", func(xfb Transform) *Collision {
+			return CollideCircleAndPolygon(circle, origin, square, xfb)
+		}},
+		{"CollidePolygons", func(xfb Transform) *Collision {
+			return CollidePolygons(square, origin, square, xfb)
+		}},
+		{"CollideBoxes", func(xfb Transform) *Collision {
+			return CollideBoxes(box, origin, box, xfb)
+		}},
+		{"CollideBoxAndCircle", func(xfb Transform) *Collision {
+			return CollideBoxAndCircle(box, origin, circle, xfb)
+		}},
+		{"CollideBoxAndPolygon", func(xfb Transform) *Collision {
+			return CollideBoxAndPolygon(box, origin, square, xfb)
+		}},
+	}
+	for _, test := range tests {
+		if c := test.collide(near); c == nil || c.Depth <= 0 {
+			t.Errorf("%s: got collision %v for overlapping shapes", test.name, c)
+		}
+		if c := test.collide(far); c != nil {
+			t.Errorf("%s: got collision %v for separated shapes", test.name, *c)
+		}
+	}
+}
+
+func TestReportPolygons(t *testing.T) {
+	square := Rectangle(Point{}, Point{1, 1})
+	arrow := NewPolygon(Point{-1, -1}, Point{1, 0}, Point{-1, 1})
+	origin := NewTransform(Point{}, 0)
+	tests := []struct {
+		name       string
+		a, b       *Polygon
+		xfb        Transform
+		margin     float64
+		collision  bool
+		axis       Point
+		separation float64
+		reference  Side
+		face       int
+	}{
+		{
+			name:       "separated by a face of A",
+			a:          square,
+			b:          square,
+			xfb:        NewTransform(Point{3, 0.5}, 0),
+			axis:       Point{1, 0},
+			separation: 1,
+			reference:  SideA,
+			face:       1,
+		},
+		{
+			name:       "separated by a rotated face of A",
+			a:          square,
+			b:          square,
+			xfb:        NewTransform(Point{0, 3}, math.Pi/4),
+			axis:       Point{0, 1},
+			separation: 2 - math.Sqrt2,
+			reference:  SideA,
+			face:       2,
+		},
+		{
+			name:       "separated by a face of B",
+			a:          arrow,
+			b:          square,
+			xfb:        NewTransform(Point{3, 0}, 0),
+			axis:       Point{1, 0},
+			separation: 1,
+			reference:  SideB,
+			face:       3,
+		},
+		{
+			name:       "overlapping",
+			a:          square,
+			b:          square,
+			xfb:        NewTransform(Point{1.5, 0}, 0),
+			collision:  true,
+			axis:       Point{1, 0},
+			separation: -0.5,
+			reference:  SideA,
+			face:       1,
+		},
+		{
+			name:       "within the margin",
+			a:          square,
+			b:          square,
+			xfb:        NewTransform(Point{0, -2.1}, 0),
+			margin:     0.2,
+			collision:  true,
+			axis:       Point{0, -1},
+			separation: 0.1,
+			reference:  SideA,
+			face:       0,
+		},
+	}
+	for _, test := range tests {
+		report := ReportPolygons(test.a, origin, test.b, test.xfb, test.margin)
+		if (report.Collision != nil) != test.collision {
+			t.Errorf("%s: got collision %v, want %v", test.name, report.Collision != nil, test.collision)
+		}
+		if !approxEqualPoint(report.Axis, test.axis) {
+			t.Errorf("%s: got axis %v, want %v", test.name, report.Axis, test.axis)
+		}
+		if !approxEqual(report.Separation, test.separation) {
+			t.Errorf("%s: got separation %v, want %v", test.name, report.Separation, test.separation)
+		}
+		if report.Reference != test.reference || report.Face != test.face {
+			t.Errorf("%s: got face %d of side %d, want face %d of side %d",
+				test.name, report.Face, report.Reference, test.face, test.reference)
+		}
+	}
+}
@@ -31,8 +31,8 @@ func (s *Simplex) ReadCache(cache *SimplexCache, a Shape, xfa Transform, b Shape
 		v := &s.v[i]
 		v.indexA = cache.indexA[i]
 		v.indexB = cache.indexB[i]
-		v.a = a.getVertex(v.indexA)
-		v.b = b.getVertex(v.indexB)
+		v.a = a.GetVertex(v.indexA)
+		v.b = b.GetVertex(v.indexB)
 		a := xfa.Mul(v.a)
 		b := xfb.Mul(v.b)
 		v.p = b.Sub(a)
@@ -77,22 +77,25 @@ func (s *Simplex) ClosestPoint() Point {
 	}
 }
 
-// WitnessPoints returns the witness points on the original shapes.
+// WitnessPoints returns the witness points on the original shapes
+// in the local space of each shape.
 func (s *Simplex) WitnessPoints() (Point, Point) {
 	switch s.count {
 	case 1:
 		// a0, b0
-		return s.v[0].a, s.v[1].b
+		return s.v[0].a, s.v[0].b
 	case 2:
 		// a0*u0 + a1*u1, b0*u0 + b1*u1
 		return s.v[0].a.Mul(s.v[0].u).Add(s.v[1].a.Mul(s.v[1].u)),
 			s.v[0].b.Mul(s.v[0].u).Add(s.v[1].b.Mul(s.v[1].u))
 	case 3:
-		// a0*u0 + a1*u1 + a2*u2
-		p := s.v[0].a.Mul(s.v[0].u).
-			Add(s.v[1].a.Mul(s.v[1].u)).
-			Add(s.v[2].a.Mul(s.v[2].u))
-		return p, p
+		// a0*u0 + a1*u1 + a2*u2, b0*u0 + b1*u1 + b2*u2
+		return s.v[0].a.Mul(s.v[0].u).
+				Add(s.v[1].a.Mul(s.v[1].u)).
+				Add(s.v[2].a.Mul(s.v[2].u)),
+			s.v[0].b.Mul(s.v[0].u).
+				Add(s.v[1].b.Mul(s.v[1].u)).
+				Add(s.v[2].b.Mul(s.v[2].u))
 	default:
 		panic("bad simplex length")
 	}
@@ -202,6 +205,7 @@ func (s *Simplex) evolveTriangle() {
 		// Reduce simplex to vertex C
 		s.count = 1
 		s.v[0] = c
+		return
 	}
 
 	// Compute signed triangle area
@@ -279,14 +283,15 @@ func (s *Simplex) Metric() float64 {
 }
 
 // GJK implements the Gilbert-Johnson-Keerthi distance algorithm.
-func (s *Simplex) GJK(a Shape, xfa Transform, b Shape, xfb Transform) {
+// It returns the number of iterations performed.
+func (s *Simplex) GJK(a Shape, xfa Transform, b Shape, xfb Transform) int {
 	// If the simplex is empty or invalid
 	if s.count == 0 {
 		// Pick arbitrary initial simplex.
 		indexA := 0
 		indexB := 0
-		va := a.getVertex(indexA)
-		vb := b.getVertex(indexB)
+		va := a.GetVertex(indexA)
+		vb := b.GetVertex(indexB)
 
 		s.count = 1
 		s.v[0] = vertex{
@@ -302,6 +307,7 @@ func (s *Simplex) GJK(a Shape, xfa Transform, b Shape, xfb Transform) {
 	var oldCount byte
 	var oldIndexA [3]int
 	var oldIndexB [3]int
+	var iterations int
 
 loop:
 	for {
@@ -327,10 +333,10 @@ loop:
 		}
 
 		// Calculate a new support point in the search direction.
-		indexA := a.getSupport(xfa.Rotation.MulT(dir.Neg()))
-		indexB := b.getSupport(xfb.Rotation.MulT(dir))
-		va := a.getVertex(indexA)
-		vb := b.getVertex(indexB)
+		indexA := a.GetSupport(xfa.Rotation.MulT(dir.Neg()))
+		indexB := b.GetSupport(xfb.Rotation.MulT(dir))
+		va := a.GetVertex(indexA)
+		vb := b.GetVertex(indexB)
 
 		support := vertex{
 			a:      va,
@@ -341,6 +347,9 @@ loop:
 			indexB: indexB,
 		}
 
+		// Iteration count is equated to the number of support point calls.
+		iterations++
+
 		// Check for duplicate support points. This is the main termination criteria.
 		for i := byte(0); i < oldCount; i++ {
 			if support.indexA == oldIndexA[i] && support.indexB == oldIndexB[i] {
@@ -352,13 +361,105 @@ loop:
 		s.v[s.count] = support
 		s.count++
 	}
+	return iterations
 }
 
-// Distance returns the distance between a and b.
-func Distance(a Shape, xfa Transform, b Shape, xfb Transform) float64 {
+// DistanceInput is the input to ShapeDistance.
+type DistanceInput struct {
+	A          Shape
+	B          Shape
+	TransformA Transform
+	TransformB Transform
+	UseRadii   bool          // account for the radii of rounded shapes
+	Cache      *SimplexCache // optional, used to warm start and updated on return
+}
+
+// DistanceOutput is the output of ShapeDistance.
+type DistanceOutput struct {
+	PointA       Point   // closest point on shape A in world space
+	PointB       Point   // closest point on shape B in world space
+	Normal       Point   // unit normal from A to B, zero if the shapes overlap
+	Distance     float64 // distance between the closest points
+	Iterations   int     // number of GJK iterations
+	SimplexCount int     // number of vertices in the final simplex
+}
+
+// ShapeDistance computes the closest points between two shapes.
+func ShapeDistance(input *DistanceInput) DistanceOutput {
+	a, xfa := input.A, input.TransformA
+	b, xfb := input.B, input.TransformB
+
 	var simplex Simplex
-	simplex.GJK(a, xfa, b, xfb)
+	if input.Cache != nil {
+		simplex.ReadCache(input.Cache, a, xfa, b, xfb)
+	}
+	iterations := simplex.GJK(a, xfa, b, xfb)
+	if input.Cache != nil {
+		simplex.WriteCache(input.Cache)
+	}
+
+	var radiusA, radiusB float64
+	if input.UseRadii {
+		radiusA, radiusB = a.GetRadius(), b.GetRadius()
+	}
+	pointA, pointB, distance := simplex.closestPoints(xfa, xfb, radiusA, radiusB)
+
+	// The normal is undefined when the shapes overlap
+	var normal Point
+	if distance > 0 {
+		normal = simplex.ClosestPoint().Normalize()
+	}
+
+	return DistanceOutput{
+		PointA:       pointA,
+		PointB:       pointB,
+		Normal:       normal,
+		Distance:     distance,
+		Iterations:   iterations,
+		SimplexCount: int(simplex.count),
+	}
+}
+
+// Distance returns the distance between the surfaces of a and b.
+// It returns zero if the shapes overlap.
+func Distance(a Shape, xfa Transform, b Shape, xfb Transform) float64 {
+	output := ShapeDistance(&DistanceInput{
+		A:          a,
+		B:          b,
+		TransformA: xfa,
+		TransformB: xfb,
+		UseRadii:   true,
+	})
+	return output.Distance
+}
+
+// closestPoints returns the closest points on the two shapes in world space
+// and the distance between them. The points are moved out onto the surfaces
+// of shapes with the given radii.
+func (s *Simplex) closestPoints(xfa, xfb Transform, radiusA, radiusB float64) (Point, Point, float64) {
+	pointA, pointB := s.WitnessPoints()
+	pointA, pointB = xfa.Mul(pointA), xfb.Mul(pointB)
+
 	// The distance between the shapes is equal to the distance
 	// between the Minkowski difference and the origin.
-	return simplex.ClosestPoint().Length()
+	distance := s.ClosestPoint().Length()
+
+	radius := radiusA + radiusB
+	if radius == 0 {
+		return pointA, pointB, distance
+	}
+
+	if distance > radius {
+		// Shapes are still not overlapped.
+		// Move the witness points to the outer surface.
+		normal := pointB.Sub(pointA).Div(distance)
+		pointA = pointA.Add(normal.Mul(radiusA))
+		pointB = pointB.Sub(normal.Mul(radiusB))
+		return pointA, pointB, distance - radius
+	}
+
+	// Shapes are overlapped when radii are considered.
+	// Move the witness points to the middle.
+	p := pointA.Add(pointB).Mul(0.5)
+	return p, p, 0
 }
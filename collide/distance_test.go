@@ -0,0 +1,261 @@
+package collide
+
+import (
+	"math"
+	"testing"
+)
+
+// capsule is a rounded segment, used to test shapes with a radius and more
+// than one vertex.
+type capsule struct {
+	Center1, Center2 Point
+	Radius           float64
+}
+
+func (c *capsule) GetSupport(dir Point) int {
+	if Dot(dir, c.Center2.Sub(c.Center1)) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func (c *capsule) GetVertex(index int) Point {
+	if index == 0 {
+		return c.Center1
+	}
+	return c.Center2
+}
+
+func (c *capsule) GetRadius() float64 {
+	return c.Radius
+}
+
+func TestDistance(t *testing.T) {
+	identity := NewTransform(Point{}, 0)
+	square := Rectangle(Point{}, Point{1, 1})
+	tests := []struct {
+		name     string
+		a        Shape
+		xfa      Transform
+		b        Shape
+		xfb      Transform
+		distance float64
+	}{
+		{
+			name:     "separated circles",
+			a:        &Circle{Radius: 1},
+			xfa:      identity,
+			b:        &Circle{Radius: 0.5},
+			xfb:      NewTransform(Point{3, 4}, 0),
+			distance: 3.5,
+		},
+		{
+			name:     "overlapping circles",
+			a:        &Circle{Radius: 1},
+			xfa:      identity,
+			b:        &Circle{Radius: 1},
+			xfb:      NewTransform(Point{1.5, 0}, 0),
+			distance: 0,
+		},
+		{
+			name:     "circle and polygon",
+			a:        square,
+			xfa:      identity,
+			b:        &Circle{Center: Point{0, 1}, Radius: 0.5},
+			xfb:      NewTransform(Point{3, 0}, 0),
+			distance: 1.5,
+		},
+		{
+			name:     "circle facing a vertex",
+			a:        square,
+			xfa:      identity,
+			b:        &Circle{Radius: 0.5},
+			xfb:      NewTransform(Point{4, 5}, 0),
+			distance: 4.5,
+		},
+		{
+			name:     "separated polygons",
+			a:        square,
+			xfa:      identity,
+			b:        square,
+			xfb:      NewTransform(Point{0.5, 3}, math.Pi/2),
+			distance: 1,
+		},
+		{
+			name:     "capsule and polygon",
+			a:        &capsule{Point{-1, 0}, Point{1, 0}, 0.25},
+			xfa:      NewTransform(Point{0, 2}, 0),
+			b:        square,
+			xfb:      identity,
+			distance: 0.75,
+		},
+		{
+			name:     "rotated capsule and circle",
+			a:        &capsule{Point{-1, 0}, Point{1, 0}, 0.25},
+			xfa:      NewTransform(Point{}, math.Pi/2),
+			b:        &Circle{Radius: 0.5},
+			xfb:      NewTransform(Point{0, 3}, 0),
+			distance: 1.25,
+		},
+		{
+			name:     "overlapping capsules",
+			a:        &capsule{Point{-1, 0}, Point{1, 0}, 0.25},
+			xfa:      identity,
+			b:        &capsule{Point{-1, 0}, Point{1, 0}, 0.25},
+			xfb:      NewTransform(Point{0, 0.4}, math.Pi/2),
+			distance: 0,
+		},
+	}
+	for _, test := range tests {
+		distance := Distance(test.a, test.xfa, test.b, test.xfb)
+		if !approxEqual(distance, test.distance) {
+			t.Errorf("%s: got distance %v, want %v", test.name, distance, test.distance)
+		}
+
+		// Distance is symmetric
+		distance = Distance(test.b, test.xfb, test.a, test.xfa)
+		if !approxEqual(distance, test.distance) {
+			t.Errorf("%s: got reversed distance %v, want %v", test.name, distance, test.distance)
+		}
+	}
+}
+
+func TestShapeDistance(t *testing.T) {
+	identity := NewTransform(Point{}, 0)
+	square := Rectangle(Point{}, Point{1, 1})
+	tests := []struct {
+		name           string
+		input          DistanceInput
+		pointA, pointB Point
+		normal         Point
+		distance       float64
+	}{
+		{
+			name: "circles",
+			input: DistanceInput{
+				A:          &Circle{Radius: 1},
+				B:          &Circle{Radius: 0.5},
+				TransformA: identity,
+				TransformB: NewTransform(Point{3, 4}, 0),
+				UseRadii:   true,
+			},
+			pointA:   Point{0.6, 0.8},
+			pointB:   Point{2.7, 3.6},
+			normal:   Point{0.6, 0.8},
+			distance: 3.5,
+		},
+		{
+			name: "circle cores",
+			input: DistanceInput{
+				A:          &Circle{Radius: 1},
+				B:          &Circle{Radius: 0.5},
+				TransformA: identity,
+				TransformB: NewTransform(Point{3, 4}, 0),
+			},
+			pointA:   Point{0, 0},
+			pointB:   Point{3, 4},
+			normal:   Point{0.6, 0.8},
+			distance: 5,
+		},
+		{
+			name: "polygon face and circle",
+			input: DistanceInput{
+				A:          square,
+				B:          &Circle{Radius: 0.5},
+				TransformA: identity,
+				TransformB: NewTransform(Point{3, 0.25}, 0),
+				UseRadii:   true,
+			},
+			pointA:   Point{1, 0.25},
+			pointB:   Point{2.5, 0.25},
+			normal:   Point{1, 0},
+			distance: 1.5,
+		},
+		{
+			name: "polygons",
+			input: DistanceInput{
+				A:          square,
+				B:          square,
+				TransformA: NewTransform(Point{0, 3}, 0),
+				TransformB: NewTransform(Point{0, 0}, math.Pi/4),
+			},
+			pointA:   Point{0, 2},
+			pointB:   Point{0, math.Sqrt2},
+			normal:   Point{0, -1},
+			distance: 2 - math.Sqrt2,
+		},
+		{
+			name: "overlapping rounded shapes",
+			input: DistanceInput{
+				A:          &capsule{Point{-1, 0}, Point{1, 0}, 0.25},
+				B:          &Circle{Radius: 0.5},
+				TransformA: identity,
+				TransformB: NewTransform(Point{0.5, 0.5}, 0),
+				UseRadii:   true,
+			},
+			distance: 0,
+		},
+		{
+			name: "overlapping polygons",
+			input: DistanceInput{
+				A:          square,
+				B:          square,
+				TransformA: identity,
+				TransformB: NewTransform(Point{0.5, 0.5}, 0),
+			},
+			distance: 0,
+		},
+	}
+	for _, test := range tests {
+		output := ShapeDistance(&test.input)
+		if !approxEqual(output.Distance, test.distance) {
+			t.Errorf("%s: got distance %v, want %v", test.name, output.Distance, test.distance)
+		}
+		if !approxEqualPoint(output.Normal, test.normal) {
+			t.Errorf("%s: got normal %v, want %v", test.name, output.Normal, test.normal)
+		}
+		if test.distance == 0 {
+			// The witness points are not unique
+			continue
+		}
+		if !approxEqualPoint(output.PointA, test.pointA) {
+			t.Errorf("%s: got point A %v, want %v", test.name, output.PointA, test.pointA)
+		}
+		if !approxEqualPoint(output.PointB, test.pointB) {
+			t.Errorf("%s: got point B %v, want %v", test.name, output.PointB, test.pointB)
+		}
+	}
+}
+
+func TestShapeDistanceIterations(t *testing.T) {
+	// Two circles take a single support point, and the second one
+	// found is a duplicate that ends the search
+	output := ShapeDistance(&DistanceInput{
+		A:          &Circle{Radius: 1},
+		B:          &Circle{Radius: 1},
+		TransformA: NewTransform(Point{}, 0),
+		TransformB: NewTransform(Point{5, 0}, 0),
+	})
+	if output.Iterations != 1 {
+		t.Errorf("got %d iterations, want 1", output.Iterations)
+	}
+
+	// A warm started search finds the same closest points
+	a := Rectangle(Point{}, Point{1, 1})
+	b := NewPolygon(Point{-1, -1}, Point{1, -1}, Point{0, 1})
+	input := DistanceInput{
+		A:          a,
+		B:          b,
+		TransformA: NewTransform(Point{}, 0.3),
+		TransformB: NewTransform(Point{3, 1}, 0.2),
+		Cache:      &SimplexCache{},
+	}
+	cold := ShapeDistance(&input)
+	warm := ShapeDistance(&input)
+	if !approxEqual(cold.Distance, warm.Distance) {
+		t.Errorf("got warm distance %v, want %v", warm.Distance, cold.Distance)
+	}
+	if warm.Iterations > cold.Iterations {
+		t.Errorf("got %d warm iterations, want at most %d", warm.Iterations, cold.Iterations)
+	}
+}
@@ -0,0 +1,258 @@
+package collide
+
+import (
+	"math"
+)
+
+// epaMaxVertices is the maximum number of vertices in the expanding polytope.
+const epaMaxVertices = 32
+
+// epaTolerance is the relative tolerance used to detect convergence.
+const epaTolerance = 1e-6
+
+// Penetration represents the penetration of two overlapping shapes.
+type Penetration struct {
+	Normal Point   // penetration normal from A to B
+	Depth  float64 // penetration depth
+	PointA Point   // deepest point on shape A in world space
+	PointB Point   // deepest point on shape B in world space
+}
+
+// polytope represents the expanding polytope.
+type polytope struct {
+	count int                    // number of vertices
+	v     [epaMaxVertices]vertex // vertices in counter-clockwise order
+}
+
+// closestEdge returns the edge of the polytope closest to the origin,
+// along with its outward normal and its distance from the origin.
+func (p *polytope) closestEdge() (int, Point, float64) {
+	index := -1
+	var normal Point
+	minDist := math.MaxFloat64
+	for i := 0; i < p.count; i++ {
+		j := i + 1
+		if j == p.count {
+			j = 0
+		}
+
+		edge := p.v[j].p.Sub(p.v[i].p)
+		if edge.LengthSquared() == 0 {
+			// Skip degenerate edges
+			continue
+		}
+
+		n := CrossPS(edge, 1.0).Normalize()
+		dist := Dot(n, p.v[i].p)
+		if dist < minDist {
+			index = i
+			normal = n
+			minDist = dist
+		}
+	}
+	return index, normal, minDist
+}
+
+// insert inserts the vertex v at index i.
+func (p *polytope) insert(i int, v vertex) {
+	copy(p.v[i+1:p.count+1], p.v[i:p.count])
+	p.v[i] = v
+	p.count++
+
+	// The initial simplex is not necessarily made of support points, so
+	// its vertices may end up inside the polytope. Remove any vertices
+	// next to the new one that are no longer convex.
+	for p.count > 3 {
+		prev := (i + p.count - 1) % p.count
+		if !p.reflex(prev) {
+			break
+		}
+		p.remove(prev)
+		if prev < i {
+			i--
+		}
+	}
+	for p.count > 3 {
+		next := (i + 1) % p.count
+		if !p.reflex(next) {
+			break
+		}
+		p.remove(next)
+		if next < i {
+			i--
+		}
+	}
+}
+
+// remove removes the vertex at index i.
+func (p *polytope) remove(i int) {
+	copy(p.v[i:p.count-1], p.v[i+1:p.count])
+	p.count--
+}
+
+// reflex reports whether the vertex at index i is not strictly convex.
+func (p *polytope) reflex(i int) bool {
+	prev := p.v[(i+p.count-1)%p.count].p
+	next := p.v[(i+1)%p.count].p
+	cur := p.v[i].p
+	return Cross(cur.Sub(prev), next.Sub(cur)) <= 0
+}
+
+// EPA implements the Expanding Polytope Algorithm. It continues from the
+// simplex computed by GJK, which must contain the origin, and expands it
+// towards the boundary of the Minkowski difference to find the penetration
+// of the two shapes.
+func (s *Simplex) EPA(a Shape, xfa Transform, b Shape, xfb Transform) Penetration {
+	penetration, _ := s.epa(a, xfa, b, xfb)
+	return penetration
+}
+
+// epa implements EPA. It also returns the vertices of the closest edge
+// of the polytope.
+func (s *Simplex) epa(a Shape, xfa Transform, b Shape, xfb Transform) (Penetration, [2]vertex) {
+	if s.count < 3 {
+		s.expand(a, xfa, b, xfb)
+	}
+	if s.count < 3 {
+		// The Minkowski difference is degenerate,
+		// so the shapes are only touching.
+		return s.touching(a, xfa, b, xfb)
+	}
+
+	// Build the initial polytope with counter-clockwise winding.
+	var poly polytope
+	poly.count = 3
+	poly.v[0], poly.v[1], poly.v[2] = s.v[0], s.v[1], s.v[2]
+	if Cross(poly.v[1].p.Sub(poly.v[0].p), poly.v[2].p.Sub(poly.v[0].p)) < 0 {
+		poly.v[1], poly.v[2] = poly.v[2], poly.v[1]
+	}
+
+	var edge int
+	var normal Point
+	var dist float64
+	for {
+		edge, normal, dist = poly.closestEdge()
+		if edge < 0 {
+			// The polytope is degenerate.
+			return s.touching(a, xfa, b, xfb)
+		}
+
+		// Calculate a new support point in the direction of the edge normal.
+		support := minkowskiSupport(a, xfa, b, xfb, normal)
+
+		// Check if the polytope can be expanded any further.
+		// This is the main termination criteria.
+		if Dot(support.p, normal)-dist <= epaTolerance*math.Max(1, dist) {
+			break
+		}
+
+		// Check for duplicate support points.
+		j := edge + 1
+		if j == poly.count {
+			j = 0
+		}
+		v1, v2 := &poly.v[edge], &poly.v[j]
+		if (support.indexA == v1.indexA && support.indexB == v1.indexB) ||
+			(support.indexA == v2.indexA && support.indexB == v2.indexB) {
+			break
+		}
+
+		if poly.count == epaMaxVertices {
+			break
+		}
+
+		// Split the edge with the new support point.
+		poly.insert(edge+1, support)
+	}
+
+	// Compute the barycentric coordinates of the closest point on the edge.
+	j := edge + 1
+	if j == poly.count {
+		j = 0
+	}
+	v1, v2 := poly.v[edge], poly.v[j]
+	e := v2.p.Sub(v1.p)
+	u := Dot(normal.Mul(dist).Sub(v1.p), e) / e.LengthSquared()
+	u = math.Max(0, math.Min(1, u))
+
+	pointA := v1.a.Mul(1 - u).Add(v2.a.Mul(u))
+	pointB := v1.b.Mul(1 - u).Add(v2.b.Mul(u))
+	return Penetration{
+		Normal: normal.Neg(),
+		Depth:  dist,
+		PointA: xfa.Mul(pointA),
+		PointB: xfb.Mul(pointB),
+	}, [2]vertex{v1, v2}
+}
+
+// expand attempts to expand a simplex that contains the origin but has
+// fewer than three vertices into a triangle. This happens when the origin
+// lies on a vertex or an edge of the simplex.
+func (s *Simplex) expand(a Shape, xfa Transform, b Shape, xfb Transform) {
+	if s.count == 1 {
+		for _, dir := range [...]Point{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			support := minkowskiSupport(a, xfa, b, xfb, dir)
+			if Dot(support.p.Sub(s.v[0].p), dir) > 0 {
+				s.v[1] = support
+				s.count = 2
+				break
+			}
+		}
+	}
+
+	if s.count == 2 {
+		normal := CrossPS(s.v[1].p.Sub(s.v[0].p), 1.0).Normalize()
+		for _, dir := range [...]Point{normal, normal.Neg()} {
+			support := minkowskiSupport(a, xfa, b, xfb, dir)
+			if Dot(support.p.Sub(s.v[0].p), dir) > 0 {
+				s.v[2] = support
+				s.count = 3
+				break
+			}
+		}
+	}
+}
+
+// minkowskiSupport returns the support point of the Minkowski difference
+// of a and b in the given direction.
+func minkowskiSupport(a Shape, xfa Transform, b Shape, xfb Transform, dir Point) vertex {
+	indexA := a.GetSupport(xfa.Rotation.MulT(dir.Neg()))
+	indexB := b.GetSupport(xfb.Rotation.MulT(dir))
+	va := a.GetVertex(indexA)
+	vb := b.GetVertex(indexB)
+	return vertex{
+		a:      va,
+		b:      vb,
+		p:      xfb.Mul(vb).Sub(xfa.Mul(va)),
+		u:      1,
+		indexA: indexA,
+		indexB: indexB,
+	}
+}
+
+// touching returns a penetration of zero depth for shapes that are touching.
+func (s *Simplex) touching(a Shape, xfa Transform, b Shape, xfb Transform) (Penetration, [2]vertex) {
+	var normal Point
+	switch s.count {
+	case 1:
+		// Choose arbitrary normal
+		normal = Point{1, 0}
+		s.v[0].u = 1
+	case 2:
+		// The Minkowski difference lies on one side of the segment.
+		normal = CrossPS(s.v[1].p.Sub(s.v[0].p), 1.0).Normalize()
+		support := minkowskiSupport(a, xfa, b, xfb, normal.Neg())
+		if Dot(support.p, normal) < 0 {
+			normal = normal.Neg()
+		}
+	default:
+		panic("bad simplex length")
+	}
+
+	pointA, pointB := s.WitnessPoints()
+	return Penetration{
+		Normal: normal,
+		PointA: xfa.Mul(pointA),
+		PointB: xfb.Mul(pointB),
+	}, [2]vertex{s.v[0], s.v[s.count-1]}
+}
@@ -0,0 +1,118 @@
+package collide
+
+import (
+	"math"
+	"testing"
+)
+
+// epsilon is the tolerance of the approximate comparisons in tests.
+const epsilon = 1e-9
+
+// approxEqual reports whether a and b are equal within epsilon.
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) <= epsilon
+}
+
+// approxEqualPoint reports whether p and q are equal within epsilon.
+func approxEqualPoint(p, q Point) bool {
+	return approxEqual(p.X, q.X) && approxEqual(p.Y, q.Y)
+}
+
+func TestEPA(t *testing.T) {
+	square := &Box{Extents: Point{1, 1}}
+	triangle := NewPolygon(Point{-1, -1}, Point{1, -1}, Point{0, 1})
+	tests := []struct {
+		name   string
+		a      Shape
+		xfa    Transform
+		b      Shape
+		xfb    Transform
+		normal Point
+		depth  float64
+	}{
+		{
+			name:   "boxes on x axis",
+			a:      square,
+			xfa:    NewTransform(Point{}, 0),
+			b:      square,
+			xfb:    NewTransform(Point{1.5, 0.2}, 0),
+			normal: Point{1, 0},
+			depth:  0.5,
+		},
+		{
+			name:   "boxes on y axis",
+			a:      square,
+			xfa:    NewTransform(Point{}, 0),
+			b:      square,
+			xfb:    NewTransform(Point{0.3, -1.6}, 0),
+			normal: Point{0, -1},
+			depth:  0.4,
+		},
+		{
+			name:   "deep overlap",
+			a:      Rectangle(Point{}, Point{2, 1}),
+			xfa:    NewTransform(Point{}, 0),
+			b:      Rectangle(Point{}, Point{2, 1}),
+			xfb:    NewTransform(Point{0, 0.1}, 0),
+			normal: Point{0, 1},
+			depth:  1.9,
+		},
+		{
+			name:   "rotated vertex into face",
+			a:      square,
+			xfa:    NewTransform(Point{}, 0),
+			b:      square,
+			xfb:    NewTransform(Point{2.2, 0}, math.Pi/4),
+			normal: Point{1, 0},
+			depth:  math.Sqrt2 - 1.2,
+		},
+		{
+			name:   "translated shapes",
+			a:      square,
+			xfa:    NewTransform(Point{10, 10}, 0),
+			b:      triangle,
+			xfb:    NewTransform(Point{10, 11.75}, 0),
+			normal: Point{0, 1},
+			depth:  0.25,
+		},
+		{
+			name:   "rotated shape A",
+			a:      square,
+			xfa:    NewTransform(Point{}, math.Pi/2),
+			b:      square,
+			xfb:    NewTransform(Point{-1.9, 0}, 0),
+			normal: Point{-1, 0},
+			depth:  0.1,
+		},
+	}
+	for _, test := range tests {
+		var s Simplex
+		s.GJK(test.a, test.xfa, test.b, test.xfb)
+		p := s.EPA(test.a, test.xfa, test.b, test.xfb)
+		if !approxEqualPoint(p.Normal, test.normal) {
+			t.Errorf("%s: got normal %v, want %v", test.name, p.Normal, test.normal)
+		}
+		if !approxEqual(p.Depth, test.depth) {
+			t.Errorf("%s: got depth %v, want %v", test.name, p.Depth, test.depth)
+		}
+
+		// The deepest points are separated by the depth along the normal
+		if d := Dot(p.PointA.Sub(p.PointB), p.Normal); !approxEqual(d, test.depth) {
+			t.Errorf("%s: got witness separation %v, want %v", test.name, d, test.depth)
+		}
+	}
+}
+
+func TestEPATouching(t *testing.T) {
+	// Shapes that touch along an edge have a degenerate Minkowski difference
+	// at the origin, and no penetration
+	a := Rectangle(Point{}, Point{1, 1})
+	xfa := NewTransform(Point{}, 0)
+	xfb := NewTransform(Point{2, 0}, 0)
+	var s Simplex
+	s.GJK(a, xfa, a, xfb)
+	p := s.EPA(a, xfa, a, xfb)
+	if !approxEqual(p.Depth, 0) {
+		t.Errorf("got depth %v, want 0", p.Depth)
+	}
+}
@@ -1,3 +1,4 @@
+//go:build ignore
 // +build ignore
 
 package main
@@ -62,11 +63,18 @@ func (e *Example) Update() error {
 		R0: e.b.Rotation,
 		R1: e.b.Rotation + e.b.AngularVelocity*dt,
 	}
-	simplex := &collide.Simplex{}
-	t := collide.TimeOfImpact(simplex, e.b.Shape, sweepB, e.a.Shape, sweepA)
+	settings := collide.PixelSettings()
+	output := collide.TimeOfImpact(&collide.TOIInput{
+		A:        e.b.Shape,
+		B:        e.a.Shape,
+		SweepA:   sweepB,
+		SweepB:   sweepA,
+		Settings: &settings,
+	})
+	t := output.Time
 	e.t = t
 
-	sweepB = sweepB.Advance(t)
+	sweepB = sweepB.Advance(t).Normalize()
 	e.b.Position = sweepB.P0
 	e.b.Position.X = math.Mod(e.b.Position.X, 180)
 	e.b.Rotation = sweepB.R0
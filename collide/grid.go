@@ -0,0 +1,376 @@
+package collide
+
+import (
+	"math"
+)
+
+// gridMaxCells is the largest number of cells that a proxy is stored in.
+// Larger proxies are kept in a list that every query checks.
+const gridMaxCells = 256
+
+// gridMaxCoord bounds the coordinates of cells, so that they fit in an int
+// on every platform.
+const gridMaxCoord = math.MaxInt32
+
+// cellKey identifies a cell of a HashGrid by its coordinates.
+type cellKey struct {
+	X, Y int
+}
+
+// gridProxy is a shape in a HashGrid.
+type gridProxy struct {
+	shape    Shape
+	xf       Transform
+	aabb     AABB
+	userData interface{}
+
+	// min and max are the corners of the range of cells overlapped
+	// by the bounding box, unless the proxy is large
+	min, max cellKey
+	large    bool
+	inUse    bool
+}
+
+// A HashGrid is a broadphase that divides space into square cells and stores
+// each shape in the cells overlapped by its bounding box. Only the cells in
+// use are stored, in a hash map keyed on their coordinates, so the grid is
+// unbounded.
+//
+// A grid is faster than a DynamicTree for many shapes of similar size, such
+// as the bullets of a shoot 'em up, as long as the cell size is about the
+// size of the shapes. Shapes that overlap more than 256 cells, or whose
+// bounding boxes are not finite, are kept in a list that every query checks,
+// so many large shapes slow the grid down.
+//
+// Proxies are identified by the integer returned by Insert, which stays valid
+// until the proxy is removed. Ids of removed proxies are reused.
+type HashGrid struct {
+	cellSize float64
+	invSize  float64
+	cells    map[cellKey][]int
+	proxies  []gridProxy
+	free     []int
+	large    []int
+
+	// spare holds the emptied id lists of deleted cells for reuse
+	spare [][]int
+}
+
+// NewHashGrid returns an empty grid with the given cell size.
+func NewHashGrid(cellSize float64) *HashGrid {
+	if cellSize <= 0 {
+		panic("collide: cell size must be positive")
+	}
+	return &HashGrid{
+		cellSize: cellSize,
+		invSize:  1 / cellSize,
+		cells:    make(map[cellKey][]int),
+	}
+}
+
+// CellSize returns the size of the cells of the grid.
+func (g *HashGrid) CellSize() float64 {
+	return g.cellSize
+}
+
+// cellOf returns the cell containing the point, which must be within the
+// range of cells.
+func (g *HashGrid) cellOf(p Point) cellKey {
+	return cellKey{
+		int(math.Floor(p.X * g.invSize)),
+		int(math.Floor(p.Y * g.invSize)),
+	}
+}
+
+// cellRange returns the range of cells overlapped by the bounding box.
+// It reports false if the range has more than gridMaxCells cells, or the
+// bounding box is not finite.
+func (g *HashGrid) cellRange(aabb AABB) (cellKey, cellKey, bool) {
+	minX, minY := math.Floor(aabb.Min.X*g.invSize), math.Floor(aabb.Min.Y*g.invSize)
+	maxX, maxY := math.Floor(aabb.Max.X*g.invSize), math.Floor(aabb.Max.Y*g.invSize)
+
+	// The comparisons are false for NaN and infinite coordinates
+	if !(minX <= maxX && minY <= maxY && (maxX-minX+1)*(maxY-minY+1) <= gridMaxCells) ||
+		!(-gridMaxCoord <= minX && maxX <= gridMaxCoord && -gridMaxCoord <= minY && maxY <= gridMaxCoord) {
+		return cellKey{}, cellKey{}, false
+	}
+	return cellKey{int(minX), int(minY)}, cellKey{int(maxX), int(maxY)}, true
+}
+
+// Insert adds a shape with the given transform to the grid and returns the
+// id of its proxy.
+func (g *HashGrid) Insert(s Shape, xf Transform, userData interface{}) int {
+	var id int
+	if n := len(g.free); n > 0 {
+		id = g.free[n-1]
+		g.free = g.free[:n-1]
+	} else {
+		g.proxies = append(g.proxies, gridProxy{})
+		id = len(g.proxies) - 1
+	}
+
+	p := &g.proxies[id]
+	p.shape = s
+	p.xf = xf
+	p.aabb = ComputeAABB(s, xf)
+	p.userData = userData
+	p.inUse = true
+	g.addToCells(id)
+	return id
+}
+
+// Update moves the shape of a proxy to the given transform.
+func (g *HashGrid) Update(id int, xf Transform) {
+	p := g.proxy(id)
+	p.xf = xf
+	p.aabb = ComputeAABB(p.shape, xf)
+
+	// Most updates stay within the same cells
+	min, max, ok := g.cellRange(p.aabb)
+	if ok && !p.large && min == p.min && max == p.max {
+		return
+	}
+	g.removeFromCells(id)
+	g.addToCells(id)
+}
+
+// Remove removes a proxy from the grid.
+func (g *HashGrid) Remove(id int) {
+	p := g.proxy(id)
+	g.removeFromCells(id)
+	*p = gridProxy{}
+	g.free = append(g.free, id)
+}
+
+// proxy returns the proxy with the given id.
+func (g *HashGrid) proxy(id int) *gridProxy {
+	if id < 0 || id >= len(g.proxies) || !g.proxies[id].inUse {
+		panic("collide: invalid proxy")
+	}
+	return &g.proxies[id]
+}
+
+// Shape returns the shape and transform of a proxy.
+func (g *HashGrid) Shape(id int) (Shape, Transform) {
+	p := g.proxy(id)
+	return p.shape, p.xf
+}
+
+// UserData returns the user data of a proxy.
+func (g *HashGrid) UserData(id int) interface{} {
+	return g.proxy(id).userData
+}
+
+// AABB returns the bounding box of a proxy.
+func (g *HashGrid) AABB(id int) AABB {
+	return g.proxy(id).aabb
+}
+
+// ProxyCount returns the number of proxies in the grid.
+func (g *HashGrid) ProxyCount() int {
+	return len(g.proxies) - len(g.free)
+}
+
+// CellCount returns the number of cells in use.
+func (g *HashGrid) CellCount() int {
+	return len(g.cells)
+}
+
+// addToCells adds a proxy to the cells overlapped by its bounding box,
+// or to the large proxies.
+func (g *HashGrid) addToCells(id int) {
+	p := &g.proxies[id]
+	var ok bool
+	p.min, p.max, ok = g.cellRange(p.aabb)
+	p.large = !ok
+	if p.large {
+		g.large = append(g.large, id)
+		return
+	}
+	for y := p.min.Y; y <= p.max.Y; y++ {
+		for x := p.min.X; x <= p.max.X; x++ {
+			key := cellKey{x, y}
+			ids, ok := g.cells[key]
+			if !ok {
+				if n := len(g.spare); n > 0 {
+					ids = g.spare[n-1]
+					g.spare = g.spare[:n-1]
+				}
+			}
+			g.cells[key] = append(ids, id)
+		}
+	}
+}
+
+// removeFromCells removes a proxy from the cells in its range, deleting
+// the cells that become empty, or from the large proxies.
+func (g *HashGrid) removeFromCells(id int) {
+	p := &g.proxies[id]
+	if p.large {
+		for i := range g.large {
+			if g.large[i] == id {
+				g.large[i] = g.large[len(g.large)-1]
+				g.large = g.large[:len(g.large)-1]
+				break
+			}
+		}
+		return
+	}
+	for y := p.min.Y; y <= p.max.Y; y++ {
+		for x := p.min.X; x <= p.max.X; x++ {
+			key := cellKey{x, y}
+			ids := g.cells[key]
+			for i := range ids {
+				if ids[i] == id {
+					ids[i] = ids[len(ids)-1]
+					ids = ids[:len(ids)-1]
+					break
+				}
+			}
+			if len(ids) == 0 {
+				delete(g.cells, key)
+				g.spare = append(g.spare, ids)
+			} else {
+				g.cells[key] = ids
+			}
+		}
+	}
+}
+
+// Query calls callback with the id of each proxy whose bounding box overlaps
+// aabb. Each proxy is reported once. The query stops if callback returns
+// false. The grid must not be modified by callback.
+//
+// Queries overlapping more than 256 cells check every proxy instead.
+func (g *HashGrid) Query(aabb AABB, callback func(id int) bool) {
+	min, max, ok := g.cellRange(aabb)
+	if !ok {
+		for id := range g.proxies {
+			p := &g.proxies[id]
+			if p.inUse && p.aabb.Overlaps(aabb) && !callback(id) {
+				return
+			}
+		}
+		return
+	}
+	for y := min.Y; y <= max.Y; y++ {
+		for x := min.X; x <= max.X; x++ {
+			for _, id := range g.cells[cellKey{x, y}] {
+				p := &g.proxies[id]
+				if !p.aabb.Overlaps(aabb) {
+					continue
+				}
+
+				// Report the proxy only from the first cell of the
+				// ranges that it shares with the query
+				if x != maxInt(p.min.X, min.X) || y != maxInt(p.min.Y, min.Y) {
+					continue
+				}
+				if !callback(id) {
+					return
+				}
+			}
+		}
+	}
+	for _, id := range g.large {
+		if g.proxies[id].aabb.Overlaps(aabb) && !callback(id) {
+			return
+		}
+	}
+}
+
+// QueryPoint calls callback with the id of each proxy whose shape contains
+// the point. The query stops if callback returns false.
+// The grid must not be modified by callback.
+func (g *HashGrid) QueryPoint(point Point, callback func(id int) bool) {
+	if cell, _, ok := g.cellRange(AABB{point, point}); ok {
+		for _, id := range g.cells[cell] {
+			if !g.testPoint(id, point, callback) {
+				return
+			}
+		}
+	}
+	for _, id := range g.large {
+		if !g.testPoint(id, point, callback) {
+			return
+		}
+	}
+}
+
+// testPoint calls callback with the id of the proxy if its shape contains the
+// point, and returns the result of callback, or true if it was not called.
+func (g *HashGrid) testPoint(id int, point Point, callback func(id int) bool) bool {
+	p := &g.proxies[id]
+	if !p.aabb.ContainsPoint(point) || !TestPoint(p.shape, p.xf, point) {
+		return true
+	}
+	return callback(id)
+}
+
+// QueryPairs calls callback with the ids of each pair of proxies whose
+// bounding boxes overlap. These are the candidate pairs to pass to Collide.
+// Each pair is reported once, with idA less than idB, in an order that only
+// depends on the sequence of changes made to the grid. The query stops if
+// callback returns false. The grid must not be modified by callback.
+func (g *HashGrid) QueryPairs(callback func(idA, idB int) bool) {
+	for idA := range g.proxies {
+		a := &g.proxies[idA]
+		if !a.inUse {
+			continue
+		}
+		if a.large {
+			// Large proxies are checked against every other proxy
+			for idB := idA + 1; idB < len(g.proxies); idB++ {
+				b := &g.proxies[idB]
+				if b.inUse && a.aabb.Overlaps(b.aabb) && !callback(idA, idB) {
+					return
+				}
+			}
+			continue
+		}
+		for _, idB := range g.large {
+			if idB > idA && a.aabb.Overlaps(g.proxies[idB].aabb) && !callback(idA, idB) {
+				return
+			}
+		}
+		for y := a.min.Y; y <= a.max.Y; y++ {
+			for x := a.min.X; x <= a.max.X; x++ {
+				for _, idB := range g.cells[cellKey{x, y}] {
+					if idB <= idA {
+						continue
+					}
+					b := &g.proxies[idB]
+					if !a.aabb.Overlaps(b.aabb) {
+						continue
+					}
+
+					// Report the pair only from the first cell that
+					// the proxies share
+					if x != maxInt(a.min.X, b.min.X) || y != maxInt(a.min.Y, b.min.Y) {
+						continue
+					}
+					if !callback(idA, idB) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// AppendPairs appends the candidate pairs of shapes reported by QueryPairs
+// to pairs, in the same order, and returns the extended slice. The pairs can
+// be collided with a Batch.
+func (g *HashGrid) AppendPairs(pairs []Pair) []Pair {
+	g.QueryPairs(func(idA, idB int) bool {
+		a, b := &g.proxies[idA], &g.proxies[idB]
+		pairs = append(pairs, Pair{
+			A:          a.shape,
+			B:          b.shape,
+			TransformA: a.xf,
+			TransformB: b.xf,
+		})
+		return true
+	})
+	return pairs
+}
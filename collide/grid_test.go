@@ -0,0 +1,213 @@
+package collide
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// gridPairs returns the pairs of proxies reported by QueryPairs, and fails
+// the test if a pair is reported twice or out of order.
+func gridPairs(t *testing.T, g *HashGrid) map[[2]int]bool {
+	pairs := map[[2]int]bool{}
+	g.QueryPairs(func(idA, idB int) bool {
+		pair := [2]int{idA, idB}
+		if idA >= idB || pairs[pair] {
+			t.Fatalf("got pair %v twice or out of order", pair)
+		}
+		pairs[pair] = true
+		return true
+	})
+	return pairs
+}
+
+func TestHashGrid(t *testing.T) {
+	// Random operations agree with brute force
+	r := rand.New(rand.NewSource(1))
+	g := NewHashGrid(3)
+	shapes := []Shape{
+		&Circle{Radius: 1},
+		&Box{Extents: Point{2, 0.5}},
+		Rect(0, 0, 7, 1),
+		Rect(0, 0, 400, 10), // overlaps too many cells
+	}
+	randomTransform := func() Transform {
+		return NewTransform(Point{r.Float64()*60 - 30, r.Float64()*60 - 30}, r.Float64()*7)
+	}
+	live := map[int]bool{}
+	for step := 0; step < 1500; step++ {
+		switch k := r.Intn(10); {
+		case k < 4 || len(live) < 5:
+			s := shapes[r.Intn(len(shapes)-1)]
+			if r.Intn(50) == 0 {
+				s = shapes[len(shapes)-1]
+			}
+			id := g.Insert(s, randomTransform(), step)
+			if g.UserData(id) != step {
+				t.Fatalf("step %d: got user data %v, want %v", step, g.UserData(id), step)
+			}
+			live[id] = true
+		case k < 5:
+			for id := range live {
+				g.Remove(id)
+				delete(live, id)
+				break
+			}
+		default:
+			for id := range live {
+				_, xf := g.Shape(id)
+				xf.Position = xf.Position.Add(Point{r.Float64()*4 - 2, r.Float64()*4 - 2})
+				g.Update(id, xf)
+				break
+			}
+		}
+		if g.ProxyCount() != len(live) {
+			t.Fatalf("step %d: got %d proxies, want %d", step, g.ProxyCount(), len(live))
+		}
+		if step%50 != 0 {
+			continue
+		}
+
+		want := map[[2]int]bool{}
+		for a := range live {
+			for b := range live {
+				if a < b && g.AABB(a).Overlaps(g.AABB(b)) {
+					want[[2]int{a, b}] = true
+				}
+			}
+		}
+		got := gridPairs(t, g)
+		if len(got) != len(want) {
+			t.Fatalf("step %d: got %d pairs, want %d", step, len(got), len(want))
+		}
+		for pair := range want {
+			if !got[pair] {
+				t.Fatalf("step %d: missing pair %v", step, pair)
+			}
+		}
+		if n := len(g.AppendPairs(nil)); n != len(want) {
+			t.Fatalf("step %d: appended %d pairs, want %d", step, n, len(want))
+		}
+
+		min := Point{r.Float64()*60 - 30, r.Float64()*60 - 30}
+		for _, query := range []AABB{
+			{min, min.Add(Point{r.Float64() * 20, r.Float64() * 20})},
+			{min, min.Add(Point{100, 100})}, // overlaps too many cells
+		} {
+			found := map[int]bool{}
+			g.Query(query, func(id int) bool {
+				if found[id] {
+					t.Fatalf("step %d: got proxy %d twice", step, id)
+				}
+				found[id] = true
+				return true
+			})
+			for id := range live {
+				if want := g.AABB(id).Overlaps(query); found[id] != want {
+					t.Fatalf("step %d: got found %v for proxy %d, want %v", step, found[id], id, want)
+				}
+			}
+		}
+
+		point := Point{r.Float64()*60 - 30, r.Float64()*60 - 30}
+		found := map[int]bool{}
+		g.QueryPoint(point, func(id int) bool {
+			found[id] = true
+			return true
+		})
+		for id := range live {
+			s, xf := g.Shape(id)
+			if want := TestPoint(s, xf, point); found[id] != want {
+				t.Fatalf("step %d: got found %v for proxy %d, want %v", step, found[id], id, want)
+			}
+		}
+	}
+
+	for id := range live {
+		g.Remove(id)
+	}
+	if g.CellCount() != 0 || len(g.large) != 0 {
+		t.Errorf("got %d cells and %d large proxies after removing every proxy", g.CellCount(), len(g.large))
+	}
+}
+
+func TestHashGridUnbounded(t *testing.T) {
+	// Infinite and far away bounding boxes do not overflow the cells
+	g := NewHashGrid(1)
+	origin := NewTransform(Point{}, 0)
+	small := g.Insert(&Circle{Radius: 0.5}, origin, nil)
+	infinite := g.Insert(&Circle{Radius: math.Inf(1)}, origin, nil)
+	far := g.Insert(&Circle{Radius: 0.5}, NewTransform(Point{1e300, 0}, 0), nil)
+	if n := g.CellCount(); n != 4 {
+		t.Errorf("got %d cells, want 4", n)
+	}
+
+	found := map[int]bool{}
+	g.Query(AABB{Point{-1, -1}, Point{1, 1}}, func(id int) bool {
+		found[id] = true
+		return true
+	})
+	if !found[small] || !found[infinite] || found[far] {
+		t.Errorf("got %v for a small query", found)
+	}
+
+	found = map[int]bool{}
+	g.Query(AABB{Point{math.Inf(-1), math.Inf(-1)}, Point{math.Inf(1), math.Inf(1)}}, func(id int) bool {
+		found[id] = true
+		return true
+	})
+	if len(found) != 3 {
+		t.Errorf("got %v for an infinite query", found)
+	}
+
+	if pairs := gridPairs(t, g); len(pairs) != 2 || !pairs[[2]int{small, infinite}] || !pairs[[2]int{infinite, far}] {
+		t.Errorf("got pairs %v", pairs)
+	}
+
+	found = map[int]bool{}
+	g.QueryPoint(Point{1e300, 0}, func(id int) bool {
+		found[id] = true
+		return true
+	})
+	if len(found) != 2 || !found[infinite] || !found[far] {
+		t.Errorf("got %v for a far point", found)
+	}
+
+	// Moving a proxy back into range stores it in the cells again
+	g.Update(far, origin)
+	g.Remove(infinite)
+	if len(g.large) != 0 {
+		t.Errorf("got %d large proxies, want 0", len(g.large))
+	}
+	if pairs := gridPairs(t, g); len(pairs) != 1 || !pairs[[2]int{small, far}] {
+		t.Errorf("got pairs %v", pairs)
+	}
+}
+
+func TestHashGridInvalidProxy(t *testing.T) {
+	g := NewHashGrid(1)
+	id := g.Insert(&Circle{Radius: 1}, NewTransform(Point{}, 0), nil)
+	g.Remove(id)
+	tests := []struct {
+		name string
+		fn   func(id int)
+	}{
+		{"Update", func(id int) { g.Update(id, NewTransform(Point{}, 0)) }},
+		{"Remove", func(id int) { g.Remove(id) }},
+		{"Shape", func(id int) { g.Shape(id) }},
+		{"UserData", func(id int) { g.UserData(id) }},
+		{"AABB", func(id int) { g.AABB(id) }},
+	}
+	for _, test := range tests {
+		for _, id := range []int{-1, id, 5} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%s: no panic for id %d", test.name, id)
+					}
+				}()
+				test.fn(id)
+			}()
+		}
+	}
+}
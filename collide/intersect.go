@@ -0,0 +1,186 @@
+package collide
+
+import (
+	"math"
+)
+
+// Area returns the area of the circle.
+func (c *Circle) Area() float64 {
+	return math.Pi * c.Radius * c.Radius
+}
+
+// Area returns the area of the polygon.
+func (p *Polygon) Area() float64 {
+	var area float64
+	for i := range p.Points {
+		j := i + 1
+		if j == len(p.Points) {
+			j = 0
+		}
+		area += Cross(p.Points[i], p.Points[j])
+	}
+	return 0.5 * area
+}
+
+// Centroid returns the centroid of the polygon.
+func (p *Polygon) Centroid() Point {
+	// Use the first vertex as the reference point to reduce round-off errors
+	origin := p.Points[0]
+
+	var center Point
+	var area float64
+	for i := 1; i+1 < len(p.Points); i++ {
+		// Triangle formed by the reference point and the edge
+		e1 := p.Points[i].Sub(origin)
+		e2 := p.Points[i+1].Sub(origin)
+		a := 0.5 * Cross(e1, e2)
+		area += a
+		center = center.Add(e1.Add(e2).Mul(a / 3))
+	}
+	if area == 0 {
+		return origin
+	}
+	return origin.Add(center.Div(area))
+}
+
+// Area returns the area of the box.
+func (b *Box) Area() float64 {
+	return 4 * b.Extents.X * b.Extents.Y
+}
+
+// IntersectPolygons returns the intersection of two polygons in world space
+// using Sutherland-Hodgman clipping. It returns nil if the polygons do not
+// overlap.
+func IntersectPolygons(a *Polygon, xfa Transform, b *Polygon, xfb Transform) *Polygon {
+	n := len(a.Points) + len(b.Points)
+	points := make([]Point, 0, n)
+	clipped := make([]Point, 0, n)
+
+	// Start with the vertices of B
+	for _, p := range b.Points {
+		points = append(points, xfb.Mul(p))
+	}
+
+	// Clip against each edge of A
+	for i := range a.Points {
+		normal := xfa.Rotation.Mul(a.Normals[i])
+		c := Dot(normal, xfa.Mul(a.Points[i]))
+
+		clipped = clipped[:0]
+		for j := range points {
+			k := j + 1
+			if k == len(points) {
+				k = 0
+			}
+			p1, p2 := points[j], points[k]
+
+			// Retrieve distances from each endpoint to the edge
+			d1 := Dot(normal, p1) - c
+			d2 := Dot(normal, p2) - c
+
+			// Keep points behind the edge
+			if d1 <= 0 {
+				clipped = append(clipped, p1)
+			}
+
+			// If the points are on different sides of the edge
+			if d1*d2 < 0 {
+				// Push intersection point
+				alpha := d1 / (d1 - d2)
+				p := p1.Add(p2.Sub(p1).Mul(alpha))
+				if p != p1 && p != p2 {
+					clipped = append(clipped, p)
+				}
+			}
+		}
+
+		points, clipped = clipped, points
+		if len(points) < 3 {
+			return nil
+		}
+	}
+
+	poly := NewPolygon(points...)
+	if poly.Area() <= 0 {
+		return nil
+	}
+	return poly
+}
+
+// IntersectionAreaCircles returns the area of the intersection of two circles.
+func IntersectionAreaCircles(a *Circle, xfa Transform, b *Circle, xfb Transform) float64 {
+	d := xfb.Mul(b.Center).Sub(xfa.Mul(a.Center)).Length()
+	r1, r2 := a.Radius, b.Radius
+
+	// Circles are separated
+	if d >= r1+r2 {
+		return 0
+	}
+
+	// One circle contains the other
+	if d <= math.Abs(r1-r2) {
+		r := math.Min(r1, r2)
+		return math.Pi * r * r
+	}
+
+	// Sum of the two circular segments of the lens
+	alpha := math.Acos((d*d + r1*r1 - r2*r2) / (2 * d * r1))
+	beta := math.Acos((d*d + r2*r2 - r1*r1) / (2 * d * r2))
+	k := (-d + r1 + r2) * (d + r1 - r2) * (d - r1 + r2) * (d + r1 + r2)
+	return r1*r1*alpha + r2*r2*beta - 0.5*math.Sqrt(math.Max(0, k))
+}
+
+// IntersectionAreaPolygonAndCircle returns the area of the intersection of
+// a polygon and a circle.
+func IntersectionAreaPolygonAndCircle(a *Polygon, xfa Transform, b *Circle, xfb Transform) float64 {
+	// Compute circle position in the frame of the polygon
+	center := xfa.MulT(xfb.Mul(b.Center))
+
+	// Sum the signed areas of the intersections of the circle with the
+	// triangles formed by the center of the circle and each edge.
+	var area float64
+	for i := range a.Points {
+		j := i + 1
+		if j == len(a.Points) {
+			j = 0
+		}
+		area += triangleCircleArea(a.Points[i].Sub(center), a.Points[j].Sub(center), b.Radius)
+	}
+	return math.Abs(area)
+}
+
+// triangleCircleArea returns the signed area of the intersection of the
+// triangle formed by the origin, p1 and p2 and a circle of radius r centered
+// at the origin.
+func triangleCircleArea(p1, p2 Point, r float64) float64 {
+	sector := func(p, q Point) float64 {
+		return 0.5 * r * r * math.Atan2(Cross(p, q), Dot(p, q))
+	}
+
+	// Solve |p1 + t * d|^2 = r^2
+	d := p2.Sub(p1)
+	a := Dot(d, d)
+	if a == 0 {
+		return 0
+	}
+	b := Dot(p1, d)
+	c := Dot(p1, p1) - r*r
+	disc := b*b - a*c
+	if disc <= 0 {
+		// The line does not cross the circle
+		return sector(p1, p2)
+	}
+
+	s := math.Sqrt(disc)
+	t1 := (-b - s) / a
+	t2 := (-b + s) / a
+	if t1 >= 1 || t2 <= 0 {
+		// The segment is outside of the circle
+		return sector(p1, p2)
+	}
+
+	// Split the segment into the parts outside and inside the circle
+	q1 := p1.Add(d.Mul(math.Max(t1, 0)))
+	q2 := p1.Add(d.Mul(math.Min(t2, 1)))
+	return sector(p1, q1) + 0.5*Cross(q1, q2) + sector(q2, p2)
+}
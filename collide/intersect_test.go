@@ -0,0 +1,121 @@
+package collide
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPolygonAreaAndCentroid(t *testing.T) {
+	tests := []struct {
+		name     string
+		polygon  *Polygon
+		area     float64
+		centroid Point
+	}{
+		{"square", Rectangle(Point{1, 2}, Point{1, 1}), 4, Point{1, 2}},
+		{"rectangle", Rect(0, 0, 4, 2), 8, Point{0, 0}},
+		{"triangle", NewPolygon(Point{0, 0}, Point{3, 0}, Point{0, 3}), 4.5, Point{1, 1}},
+		{"quadrilateral", NewPolygon(Point{0, 0}, Point{2, 0}, Point{2, 2}, Point{0, 1}), 3, Point{1 + 1.0/9, 7.0 / 9}},
+	}
+	for _, test := range tests {
+		if area := test.polygon.Area(); !approxEqual(area, test.area) {
+			t.Errorf("%s: got area %v, want %v", test.name, area, test.area)
+		}
+		if centroid := test.polygon.Centroid(); !approxEqualPoint(centroid, test.centroid) {
+			t.Errorf("%s: got centroid %v, want %v", test.name, centroid, test.centroid)
+		}
+	}
+}
+
+func TestIntersectPolygons(t *testing.T) {
+	square := Rectangle(Point{}, Point{1, 1})
+	triangle := NewPolygon(Point{-1, -1}, Point{1, -1}, Point{0, 1})
+	origin := NewTransform(Point{}, 0)
+	tests := []struct {
+		name     string
+		a, b     *Polygon
+		xfb      Transform
+		area     float64
+		centroid Point
+	}{
+		{"offset squares", square, square, NewTransform(Point{1, 1}, 0), 1, Point{0.5, 0.5}},
+		{"contained square", Rect(0, 0, 4, 4), square, NewTransform(Point{0.5, 0}, 0), 4, Point{0.5, 0}},
+		{"containing square", square, Rect(0, 0, 4, 4), NewTransform(Point{0.5, 0}, 0), 4, Point{0, 0}},
+		{"rotated square", square, square, NewTransform(Point{}, math.Pi/4), 8 * (math.Sqrt2 - 1), Point{0, 0}},
+		{"triangle", square, triangle, NewTransform(Point{0, 1}, 0), 1.5, Point{0, 4.0 / 9}},
+		{"separated squares", square, square, NewTransform(Point{3, 0}, 0), 0, Point{}},
+		{"touching squares", square, square, NewTransform(Point{2, 0}, 0), 0, Point{}},
+	}
+	for _, test := range tests {
+		poly := IntersectPolygons(test.a, origin, test.b, test.xfb)
+		if test.area == 0 {
+			if poly != nil {
+				t.Errorf("%s: got intersection %v, want nil", test.name, poly.Points)
+			}
+			continue
+		}
+		if poly == nil {
+			t.Errorf("%s: got no intersection", test.name)
+			continue
+		}
+		if area := poly.Area(); !approxEqual(area, test.area) {
+			t.Errorf("%s: got area %v, want %v", test.name, area, test.area)
+		}
+		if centroid := poly.Centroid(); !approxEqualPoint(centroid, test.centroid) {
+			t.Errorf("%s: got centroid %v, want %v", test.name, centroid, test.centroid)
+		}
+	}
+}
+
+func TestIntersectionAreaCircles(t *testing.T) {
+	// Area of the lens of two circles of radius r at distance d
+	lens := func(r, d float64) float64 {
+		return 2*r*r*math.Acos(d/(2*r)) - d/2*math.Sqrt(4*r*r-d*d)
+	}
+	origin := NewTransform(Point{}, 0)
+	tests := []struct {
+		name   string
+		a, b   *Circle
+		offset Point
+		area   float64
+	}{
+		{"separated", &Circle{Radius: 1}, &Circle{Radius: 1}, Point{3, 0}, 0},
+		{"touching", &Circle{Radius: 1}, &Circle{Radius: 1}, Point{0, 2}, 0},
+		{"concentric", &Circle{Radius: 1}, &Circle{Radius: 2}, Point{}, math.Pi},
+		{"contained", &Circle{Radius: 3}, &Circle{Radius: 1}, Point{1, 1}, math.Pi},
+		{"lens", &Circle{Radius: 1}, &Circle{Radius: 1}, Point{1, 0}, lens(1, 1)},
+		{"offset centers", &Circle{Center: Point{1, 0}, Radius: 2}, &Circle{Center: Point{-1, 0}, Radius: 2}, Point{3, 0}, lens(2, 1)},
+	}
+	for _, test := range tests {
+		area := IntersectionAreaCircles(test.a, origin, test.b, NewTransform(test.offset, 0))
+		if !approxEqual(area, test.area) {
+			t.Errorf("%s: got area %v, want %v", test.name, area, test.area)
+		}
+	}
+}
+
+func TestIntersectionAreaPolygonAndCircle(t *testing.T) {
+	square := Rectangle(Point{}, Point{1, 1})
+	big := Rectangle(Point{}, Point{5, 5})
+	tests := []struct {
+		name    string
+		polygon *Polygon
+		xf      Transform
+		circle  *Circle
+		center  Point
+		area    float64
+	}{
+		{"separated", square, NewTransform(Point{}, 0), &Circle{Radius: 1}, Point{3, 0}, 0},
+		{"circle inside", big, NewTransform(Point{}, 0), &Circle{Radius: 1}, Point{1, 2}, math.Pi},
+		{"polygon inside", square, NewTransform(Point{}, 0.3), &Circle{Radius: 2}, Point{}, 4},
+		{"circle on an edge", big, NewTransform(Point{}, 0), &Circle{Radius: 1}, Point{5, 0}, math.Pi / 2},
+		{"circle on a corner", big, NewTransform(Point{}, 0), &Circle{Radius: 1}, Point{-5, 5}, math.Pi / 4},
+		{"rotated polygon", big, NewTransform(Point{1, 1}, math.Pi/2), &Circle{Center: Point{1, 0}, Radius: 1}, Point{5, 6}, math.Pi / 4},
+	}
+	for _, test := range tests {
+		area := IntersectionAreaPolygonAndCircle(test.polygon, test.xf, test.circle, NewTransform(test.center, 0))
+		if !approxEqual(area, test.area) {
+			t.Errorf("%s: got area %v, want %v", test.name, area, test.area)
+		}
+	}
+}
@@ -106,6 +106,11 @@ func (r Rotation) MulT(p Point) Point {
 	}
 }
 
+// isIdentity reports whether the rotation is the identity rotation.
+func (r Rotation) isIdentity() bool {
+	return r.Sin == 0 && r.Cos == 1
+}
+
 // A Transform represents translation and rotation.
 type Transform struct {
 	Position Point
@@ -130,26 +135,67 @@ func (t Transform) MulT(p Point) Point {
 	return t.Rotation.MulT(p.Sub(t.Position))
 }
 
-// A Sweep interpolates between two positions and orientations.
+// A Sweep interpolates between two positions and orientations of a shape.
+// The positions are those of the center of mass, so that the shape rotates
+// about its center of mass rather than its origin.
+//
+// A sweep may also interpolate a uniform scale of the shape about its center
+// of mass, which scales the radius of circles as well. The scale is only
+// used if Scaled is set, so that the zero Sweep keeps the shape unscaled and
+// a scale of zero remains valid.
 type Sweep struct {
-	P0, P1 Point   // position
-	R0, R1 float64 // rotation
+	LocalCenter Point   // center of mass in local space
+	P0, P1      Point   // center of mass in world space
+	R0, R1      float64 // rotation
+	S0, S1      float64 // uniform scale
+	Scaled      bool    // whether S0 and S1 are used
 }
 
 // GetTransform returns the transform at time t.
 func (s Sweep) GetTransform(t float64) Transform {
-	return NewTransform(
+	xf := NewTransform(
 		s.P0.Mul(1-t).Add(s.P1.Mul(t)),
 		s.R0*(1-t)+s.R1*t,
 	)
+
+	// Shift to origin
+	xf.Position = xf.Position.Sub(xf.Rotation.Mul(s.LocalCenter))
+	return xf
+}
+
+// GetScale returns the scale at time t, which is 1 unless Scaled is set.
+func (s Sweep) GetScale(t float64) float64 {
+	if !s.Scaled {
+		return 1
+	}
+	return s.S0*(1-t) + s.S1*t
+}
+
+// scaled reports whether the sweep scales the shape.
+func (s Sweep) scaled() bool {
+	return s.GetScale(0) != 1 || s.GetScale(1) != 1
 }
 
-// Advance advances the position and rotation to time t.
+// Advance advances the position, rotation and scale to time t.
 func (s Sweep) Advance(t float64) Sweep {
 	return Sweep{
-		P0: s.P0.Mul(1 - t).Add(s.P1.Mul(t)),
-		P1: s.P1,
-		R0: s.R0*(1-t) + s.R1*t,
-		R1: s.R1,
+		LocalCenter: s.LocalCenter,
+		P0:          s.P0.Mul(1 - t).Add(s.P1.Mul(t)),
+		P1:          s.P1,
+		R0:          s.R0*(1-t) + s.R1*t,
+		R1:          s.R1,
+		S0:          s.S0*(1-t) + s.S1*t,
+		S1:          s.S1,
+		Scaled:      s.Scaled,
 	}
 }
+
+// Normalize returns the sweep with R0 in [0, 2π), keeping the difference
+// between R0 and R1. It keeps the rotations bounded over long simulations.
+func (s Sweep) Normalize() Sweep {
+	const twoPi = 2 * math.Pi
+	d := twoPi * math.Floor(s.R0/twoPi)
+	s.R0 -= d
+	s.R1 -= d
+	return s
+}
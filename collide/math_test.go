@@ -0,0 +1,83 @@
+package collide
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSweepGetTransform(t *testing.T) {
+	// The shape rotates about its center of mass
+	square := Rect(11, 1, 2, 2)
+	center := square.Centroid()
+	sweep := Sweep{LocalCenter: center, P0: Point{11, 1}, P1: Point{15, 1}, R1: math.Pi}
+	for _, time := range []float64{0, 0.25, 0.5, 1} {
+		xf := sweep.GetTransform(time)
+		want := Point{11 + 4*time, 1}
+		if got := xf.Mul(center); !approxEqualPoint(got, want) {
+			t.Errorf("got center %v at time %v, want %v", got, time, want)
+		}
+		if got, want := xf.Rotation, NewRotation(math.Pi*time); !approxEqual(got.Cos, want.Cos) || !approxEqual(got.Sin, want.Sin) {
+			t.Errorf("got rotation %v at time %v, want %v", got, time, want)
+		}
+	}
+}
+
+func TestSweepAdvance(t *testing.T) {
+	sweep := Sweep{LocalCenter: Point{1, 2}, P0: Point{0, 0}, P1: Point{4, 2}, R0: 0.5, R1: 2.5, S0: 1, S1: 3, Scaled: true}
+	advanced := sweep.Advance(0.25)
+	for _, time := range []float64{0, 0.5, 1} {
+		// Time in the advanced sweep is relative to the remaining interval
+		got := advanced.GetTransform(time)
+		want := sweep.GetTransform(0.25 + 0.75*time)
+		if !approxEqualPoint(got.Position, want.Position) || !approxEqual(got.Rotation.Cos, want.Rotation.Cos) ||
+			!approxEqual(got.Rotation.Sin, want.Rotation.Sin) {
+			t.Errorf("got transform %v at time %v, want %v", got, time, want)
+		}
+		if got, want := advanced.GetScale(time), sweep.GetScale(0.25+0.75*time); !approxEqual(got, want) {
+			t.Errorf("got scale %v at time %v, want %v", got, time, want)
+		}
+	}
+
+	// A shape shrinking to nothing stays scaled at the end of the sweep
+	shrinking := Sweep{S0: 1, S1: 0, Scaled: true}.Advance(1)
+	if got := shrinking.GetScale(0.5); got != 0 {
+		t.Errorf("got scale %v after shrinking to zero, want 0", got)
+	}
+	if !shrinking.scaled() {
+		t.Error("got an unscaled sweep after shrinking to zero")
+	}
+}
+
+func TestSweepNormalize(t *testing.T) {
+	for _, r0 := range []float64{0, 1, 2 * math.Pi, 13, -13, 1000} {
+		sweep := Sweep{R0: r0, R1: r0 + 1}.Normalize()
+		if sweep.R0 < 0 || sweep.R0 >= 2*math.Pi {
+			t.Errorf("got R0 %v for %v, want it in [0, 2π)", sweep.R0, r0)
+		}
+		if !approxEqual(sweep.R1-sweep.R0, 1) {
+			t.Errorf("got R1 - R0 %v for %v, want 1", sweep.R1-sweep.R0, r0)
+		}
+		if !approxEqual(math.Remainder(sweep.R0-r0, 2*math.Pi), 0) {
+			t.Errorf("got R0 %v for %v, want the same angle", sweep.R0, r0)
+		}
+	}
+}
+
+func TestSweepLocalCenter(t *testing.T) {
+	// A bar far from its origin spins in place into a box above its center
+	bar := Rect(12, 0, 4, 0.2)
+	box := Rectangle(Point{12, 1.5}, Point{0.25, 0.25})
+	input := TOIInput{
+		A:      bar,
+		B:      box,
+		SweepA: Sweep{LocalCenter: bar.Centroid(), P0: Point{12, 0}, P1: Point{12, 0}, R1: math.Pi / 2},
+	}
+	output := TimeOfImpact(&input)
+	if output.State != TOIHit {
+		t.Fatalf("got state %v, want %v", output.State, TOIHit)
+	}
+	settings := MeterSettings()
+	if distance := coreDistance(&input, output.Time); math.Abs(distance-settings.LinearSlop) > settings.TOITolerance {
+		t.Errorf("got distance %v at the time of impact, want %v", distance, settings.LinearSlop)
+	}
+}
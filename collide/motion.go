@@ -0,0 +1,75 @@
+package collide
+
+import (
+	"math"
+)
+
+// A Motion describes the trajectory of a shape over the time interval [0, 1].
+// Sweep, Ballistic and Orbit implement Motion.
+type Motion interface {
+	// GetTransform returns the transform at time t.
+	GetTransform(t float64) Transform
+}
+
+// Bound returns an upper bound on the distance moved per unit time by any
+// point of a shape whose points are within extent of its origin.
+func (s Sweep) Bound(extent float64) float64 {
+	// Bound the distance of the points from the center of mass
+	extent += s.LocalCenter.Length()
+	s0, s1 := s.GetScale(0), s.GetScale(1)
+	return s.P1.Sub(s.P0).Length() +
+		math.Abs(s.R1-s.R0)*math.Max(s0, s1)*extent +
+		math.Abs(s1-s0)*extent
+}
+
+// Ballistic is a motion under constant acceleration, such as the parabolic
+// arc of a projectile under gravity. The shape rotates about its origin at a
+// constant angular velocity.
+type Ballistic struct {
+	Position        Point   // position at time zero
+	Velocity        Point   // velocity at time zero
+	Acceleration    Point   // constant acceleration
+	Rotation        float64 // rotation at time zero
+	AngularVelocity float64 // constant angular velocity
+}
+
+// GetTransform returns the transform at time t.
+func (b Ballistic) GetTransform(t float64) Transform {
+	return NewTransform(
+		b.Position.Add(b.Velocity.Mul(t)).Add(b.Acceleration.Mul(0.5*t*t)),
+		b.Rotation+b.AngularVelocity*t,
+	)
+}
+
+// Bound returns an upper bound on the distance moved per unit time by any
+// point of a shape whose points are within extent of its origin.
+func (b Ballistic) Bound(extent float64) float64 {
+	// The speed is greatest at one of the ends of the interval
+	speed := math.Max(b.Velocity.Length(), b.Velocity.Add(b.Acceleration).Length())
+	return speed + math.Abs(b.AngularVelocity)*extent
+}
+
+// Orbit is a motion about a fixed pivot at a constant angular velocity,
+// such as a turret swinging around its mount.
+type Orbit struct {
+	Pivot           Point   // center of rotation in world space
+	Position        Point   // position at time zero
+	Rotation        float64 // rotation at time zero
+	AngularVelocity float64 // constant angular velocity
+}
+
+// GetTransform returns the transform at time t.
+func (o Orbit) GetTransform(t float64) Transform {
+	angle := o.AngularVelocity * t
+	r := NewRotation(angle)
+	return NewTransform(
+		o.Pivot.Add(r.Mul(o.Position.Sub(o.Pivot))),
+		o.Rotation+angle,
+	)
+}
+
+// Bound returns an upper bound on the distance moved per unit time by any
+// point of a shape whose points are within extent of its origin.
+func (o Orbit) Bound(extent float64) float64 {
+	return math.Abs(o.AngularVelocity) * (o.Position.Sub(o.Pivot).Length() + extent)
+}
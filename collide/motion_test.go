@@ -0,0 +1,138 @@
+package collide
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMotionGetTransform(t *testing.T) {
+	ballistic := Ballistic{Position: Point{1, 2}, Velocity: Point{10, 10}, Acceleration: Point{0, -20}, AngularVelocity: 1}
+	if got, want := ballistic.GetTransform(0.5).Position, (Point{6, 4.5}); !approxEqualPoint(got, want) {
+		t.Errorf("got ballistic position %v, want %v", got, want)
+	}
+	if got, want := ballistic.GetTransform(1).Position, (Point{11, 2}); !approxEqualPoint(got, want) {
+		t.Errorf("got ballistic position %v, want %v", got, want)
+	}
+
+	orbit := Orbit{Pivot: Point{1, 1}, Position: Point{3, 1}, AngularVelocity: math.Pi}
+	if got, want := orbit.GetTransform(0.5).Position, (Point{1, 3}); !approxEqualPoint(got, want) {
+		t.Errorf("got orbit position %v, want %v", got, want)
+	}
+	if got, want := orbit.GetTransform(1).Position, (Point{-1, 1}); !approxEqualPoint(got, want) {
+		t.Errorf("got orbit position %v, want %v", got, want)
+	}
+}
+
+func TestMotionBound(t *testing.T) {
+	// Points within the extent never move faster than the bound
+	const extent = 2
+	tests := []struct {
+		name   string
+		motion interface {
+			Motion
+			Bound(extent float64) float64
+		}
+	}{
+		{"sweep", Sweep{LocalCenter: Point{0.5, 0}, P0: Point{1, 2}, P1: Point{-3, 4}, R0: 1, R1: -2}},
+		{"ballistic", Ballistic{Velocity: Point{10, 10}, Acceleration: Point{0, -20}, AngularVelocity: 3}},
+		{"orbit", Orbit{Pivot: Point{1, 1}, Position: Point{4, 5}, Rotation: 0.5, AngularVelocity: -2 * math.Pi}},
+	}
+	points := []Point{{extent, 0}, {0, -extent}, {-1, 1}}
+	const steps = 1000
+	for _, test := range tests {
+		bound := test.motion.Bound(extent)
+		for i := 0; i < steps; i++ {
+			xf0 := test.motion.GetTransform(float64(i) / steps)
+			xf1 := test.motion.GetTransform(float64(i+1) / steps)
+			for _, p := range points {
+				if speed := xf1.Mul(p).Sub(xf0.Mul(p)).Length() * steps; speed > bound {
+					t.Errorf("%s: got speed %v at time %v, want at most %v", test.name, speed, float64(i)/steps, bound)
+				}
+			}
+		}
+	}
+}
+
+func TestTimeOfImpactMotion(t *testing.T) {
+	settings := MeterSettings()
+	ball := &Circle{Radius: 0.2}
+	arm := Rect(3, 0, 2, 0.2)
+	projectile := Ballistic{Velocity: Point{10, 10}, Acceleration: Point{0, -20}}
+	turret := Orbit{AngularVelocity: 2 * math.Pi}
+	tests := []struct {
+		name  string
+		input TOIInput
+	}{
+		{
+			name: "ballistic",
+			input: TOIInput{
+				A:           ball,
+				B:           Rect(5, 0, 1, 10),
+				MotionA:     projectile,
+				MotionBound: projectile.Bound(0),
+			},
+		},
+		{
+			name: "orbit",
+			input: TOIInput{
+				A:           arm,
+				B:           Rect(-2, -2, 1, 1),
+				MotionA:     turret,
+				MotionBound: turret.Bound(4.01),
+			},
+		},
+	}
+	for _, test := range tests {
+		output := TimeOfImpact(&test.input)
+		if output.State != TOIHit {
+			t.Errorf("%s: got state %v, want %v", test.name, output.State, TOIHit)
+			continue
+		}
+		radius := test.input.A.GetRadius() + test.input.B.GetRadius()
+		target := math.Max(settings.LinearSlop, radius+settings.TOITarget)
+		if distance := coreDistance(&test.input, output.Time); math.Abs(distance-target) > settings.TOITolerance {
+			t.Errorf("%s: got distance %v at the time of impact, want %v", test.name, distance, target)
+		}
+
+		// Conservative advancement never steps over an earlier contact
+		for i := 0; i < 1000; i++ {
+			time := output.Time * float64(i) / 1000
+			if distance := coreDistance(&test.input, time); distance < target-settings.TOITolerance {
+				t.Errorf("%s: got distance %v at time %v before the time of impact %v", test.name, distance, time, output.Time)
+				break
+			}
+		}
+	}
+}
+
+func TestTimeOfImpactMotionFailed(t *testing.T) {
+	// A loose bound takes steps too small to reach the contact within the
+	// maximum number of iterations
+	settings := MeterSettings()
+	turret := Orbit{AngularVelocity: 2 * math.Pi}
+	input := TOIInput{
+		A:           Rect(3, 0, 2, 0.2),
+		B:           Rect(-2, -2, 1, 1),
+		MotionA:     turret,
+		MotionBound: turret.Bound(1000),
+	}
+	output := TimeOfImpact(&input)
+	if output.State != TOIFailed {
+		t.Fatalf("got state %v, want %v", output.State, TOIFailed)
+	}
+	if output.Iterations != settings.TOIMaxIterations {
+		t.Errorf("got %v iterations, want %v", output.Iterations, settings.TOIMaxIterations)
+	}
+
+	// The time reached is safe to advance to
+	input.MotionBound = turret.Bound(4.01)
+	hit := TimeOfImpact(&input)
+	if output.Time <= 0 || output.Time >= hit.Time {
+		t.Errorf("got time %v, want within (0, %v)", output.Time, hit.Time)
+	}
+	radius := input.A.GetRadius() + input.B.GetRadius()
+	target := math.Max(settings.LinearSlop, radius+settings.TOITarget)
+	if distance := coreDistance(&input, output.Time); distance < target {
+		t.Errorf("got distance %v at time %v, want at least %v", distance, output.Time, target)
+	}
+}
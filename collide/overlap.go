@@ -0,0 +1,156 @@
+package collide
+
+import (
+	"math"
+)
+
+// Overlap reports whether two shapes overlap. It is faster than Collide
+// since it exits as soon as the answer is known and computes no collision.
+// Shapes of other types use the collider registered for their types, if any,
+// and otherwise GJK, which assumes that they are convex.
+func Overlap(a Shape, xfa Transform, b Shape, xfb Transform) bool {
+	switch a := a.(type) {
+	case *Circle:
+		switch b := b.(type) {
+		case *Circle:
+			return OverlapCircles(a, xfa, b, xfb)
+		case *Polygon:
+			return OverlapPolygonAndCircle(b, xfb, a, xfa)
+		case *Box:
+			return OverlapBoxAndCircle(b, xfb, a, xfa)
+		}
+	case *Polygon:
+		switch b := b.(type) {
+		case *Circle:
+			return OverlapPolygonAndCircle(a, xfa, b, xfb)
+		case *Polygon:
+			return OverlapPolygons(a, xfa, b, xfb)
+		case *Box:
+			return OverlapBoxAndPolygon(b, xfb, a, xfa)
+		}
+	case *Box:
+		switch b := b.(type) {
+		case *Circle:
+			return OverlapBoxAndCircle(a, xfa, b, xfb)
+		case *Polygon:
+			return OverlapBoxAndPolygon(a, xfa, b, xfb)
+		case *Box:
+			return OverlapBoxes(a, xfa, b, xfb)
+		}
+	}
+	if fn, flip := lookupCollider(a, b); fn != nil {
+		var c Collision
+		if flip {
+			return fn(&c, b, xfb, a, xfa, 0)
+		}
+		return fn(&c, a, xfa, b, xfb, 0)
+	}
+	return overlapConvex(a, xfa, b, xfb)
+}
+
+// overlapConvex reports whether two arbitrary convex shapes overlap using GJK.
+func overlapConvex(a Shape, xfa Transform, b Shape, xfb Transform) bool {
+	var simplex Simplex
+	simplex.GJK(a, xfa, b, xfb)
+	if simplex.count == 3 {
+		// The origin is inside the Minkowski difference
+		return true
+	}
+	_, _, distance := simplex.closestPoints(xfa, xfb, 0, 0)
+	return distance < a.GetRadius()+b.GetRadius()
+}
+
+func OverlapCircles(a *Circle, xfa Transform, b *Circle, xfb Transform) bool {
+	d := xfb.Mul(b.Center).Sub(xfa.Mul(a.Center))
+	r := a.Radius + b.Radius
+	return d.LengthSquared() < r*r
+}
+
+func OverlapPolygonAndCircle(a *Polygon, xfa Transform, b *Circle, xfb Transform) bool {
+	// Compute circle position in the frame of the polygon
+	center := xfa.MulT(xfb.Mul(b.Center))
+
+	// Look for a separating edge
+	for i := range a.Points {
+		if Dot(a.Normals[i], center.Sub(a.Points[i])) >= b.Radius {
+			// Early out
+			return false
+		}
+	}
+
+	// The circle may still be outside a vertex
+	_, _, separation := a.closestFeature(center)
+	return separation < b.Radius
+}
+
+func OverlapPolygons(a *Polygon, xfa Transform, b *Polygon, xfb Transform) bool {
+	return !hasSeparatingAxis(a, xfa, b, xfb) && !hasSeparatingAxis(b, xfb, a, xfa)
+}
+
+func OverlapBoxes(a *Box, xfa Transform, b *Box, xfb Transform) bool {
+	if !xfa.Rotation.isIdentity() || !xfb.Rotation.isIdentity() {
+		var pointsA, pointsB [4]Point
+		polyA, polyB := a.polygon(&pointsA), b.polygon(&pointsB)
+		return OverlapPolygons(&polyA, xfa, &polyB, xfb)
+	}
+	d := xfb.Position.Add(b.Center).Sub(xfa.Position.Add(a.Center))
+	return math.Abs(d.X) < a.Extents.X+b.Extents.X &&
+		math.Abs(d.Y) < a.Extents.Y+b.Extents.Y
+}
+
+func OverlapBoxAndCircle(a *Box, xfa Transform, b *Circle, xfb Transform) bool {
+	if !xfa.Rotation.isIdentity() {
+		var points [4]Point
+		poly := a.polygon(&points)
+		return OverlapPolygonAndCircle(&poly, xfa, b, xfb)
+	}
+
+	// Find the distance from the circle to the closest point on the box
+	d := xfb.Mul(b.Center).Sub(xfa.Position.Add(a.Center))
+	if math.Abs(d.X) < a.Extents.X && math.Abs(d.Y) < a.Extents.Y {
+		// The center is inside the box
+		return true
+	}
+	dx := math.Max(0, math.Abs(d.X)-a.Extents.X)
+	dy := math.Max(0, math.Abs(d.Y)-a.Extents.Y)
+	return dx*dx+dy*dy < b.Radius*b.Radius
+}
+
+func OverlapBoxAndPolygon(a *Box, xfa Transform, b *Polygon, xfb Transform) bool {
+	var points [4]Point
+	if !xfa.Rotation.isIdentity() {
+		poly := a.polygon(&points)
+		return OverlapPolygons(&poly, xfa, b, xfb)
+	}
+	if _, separation := findBoxSeparation(a, xfa, b, xfb); separation >= 0 {
+		return false
+	}
+	poly := a.polygon(&points)
+	return !hasSeparatingAxis(b, xfb, &poly, xfa)
+}
+
+// hasSeparatingAxis reports whether any edge normal of a separates a and b.
+func hasSeparatingAxis(a *Polygon, xfa Transform, b *Polygon, xfb Transform) bool {
+	for i := range a.Points {
+		// Retrieve a face normal from A in B's model space
+		n := a.Normals[i]
+		n = xfa.Rotation.Mul(n)
+		n = xfb.Rotation.MulT(n)
+		v := a.Points[i]
+		v = xfa.Mul(v)
+		v = xfb.MulT(v)
+
+		// Check if every point of B is in front of the face
+		separated := true
+		for j := range b.Points {
+			if Dot(n, b.Points[j].Sub(v)) < 0 {
+				separated = false
+				break
+			}
+		}
+		if separated {
+			return true
+		}
+	}
+	return false
+}
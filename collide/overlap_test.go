@@ -0,0 +1,113 @@
+package collide
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestOverlap(t *testing.T) {
+	circle := &Circle{Radius: 1}
+	square := Rectangle(Point{}, Point{1, 1})
+	box := &Box{Extents: Point{1, 1}}
+	origin := NewTransform(Point{}, 0)
+	tests := []struct {
+		name    string
+		a       Shape
+		b       Shape
+		xfb     Transform
+		overlap bool
+	}{
+		{"circles", circle, circle, NewTransform(Point{1.9, 0}, 0), true},
+		{"separated circles", circle, circle, NewTransform(Point{1.5, 1.5}, 0), false},
+		{"touching circles", circle, circle, NewTransform(Point{2, 0}, 0), false},
+		{"polygon and circle", square, circle, NewTransform(Point{1.9, 0.5}, 0), true},
+		{"circle outside polygon vertex", square, circle, NewTransform(Point{1.75, 1.75}, 0), false},
+		{"circle and polygon", circle, square, NewTransform(Point{0, 1.9}, 0), true},
+		{"polygons", square, square, NewTransform(Point{1.9, 1.9}, 0), true},
+		{"separated polygons", square, square, NewTransform(Point{2.1, 0}, 0), false},
+		{"rotated polygons", square, square, NewTransform(Point{2.3, 0}, math.Pi/4), true},
+		{"separated rotated polygons", square, square, NewTransform(Point{2.5, 0}, math.Pi/4), false},
+		{"boxes", box, box, NewTransform(Point{1.9, -1.9}, 0), true},
+		{"separated boxes", box, box, NewTransform(Point{0, 2.1}, 0), false},
+		{"rotated boxes", box, box, NewTransform(Point{2.3, 0}, math.Pi/4), true},
+		{"box and circle", box, circle, NewTransform(Point{1.6, 1.6}, 0), true},
+		{"circle outside box vertex", box, circle, NewTransform(Point{1.75, 1.75}, 0), false},
+		{"box and polygon", box, square, NewTransform(Point{-1.9, 0}, 0.1), true},
+		{"separated box and polygon", box, square, NewTransform(Point{-2.5, 0}, 0.1), false},
+		{"convex shapes", hull{square}, circle, NewTransform(Point{1.9, 0}, 0), true},
+		{"separated convex shapes", hull{square}, circle, NewTransform(Point{2.1, 0}, 0), false},
+	}
+	for _, test := range tests {
+		if got := Overlap(test.a, origin, test.b, test.xfb); got != test.overlap {
+			t.Errorf("%s: got overlap %v, want %v", test.name, got, test.overlap)
+		}
+	}
+}
+
+func TestOverlapCollide(t *testing.T) {
+	// Overlap agrees with Collide for random pairs of shapes
+	r := rand.New(rand.NewSource(1))
+	square := Rectangle(Point{}, Point{1, 1})
+	shapes := []Shape{
+		&Circle{Radius: 1},
+		&Circle{Center: Point{0.5, 0}, Radius: 0.5},
+		square,
+		NewPolygon(Point{-1, -1}, Point{1, -1}, Point{0, 1}),
+		&Box{Center: Point{0, 0.5}, Extents: Point{1, 0.5}},
+		hull{square},
+	}
+	for i := 0; i < 10000; i++ {
+		a := shapes[r.Intn(len(shapes))]
+		b := shapes[r.Intn(len(shapes))]
+		xfa := NewTransform(Point{r.Float64()*4 - 2, r.Float64()*4 - 2}, 0)
+		xfb := NewTransform(Point{r.Float64()*4 - 2, r.Float64()*4 - 2}, 0)
+		if r.Intn(2) == 0 {
+			xfa = NewTransform(xfa.Position, r.Float64()*2*math.Pi)
+			xfb = NewTransform(xfb.Position, r.Float64()*2*math.Pi)
+		}
+		overlap := Overlap(a, xfa, b, xfb)
+		c := Collide(a, xfa, b, xfb)
+		if overlap != (c != nil) {
+			t.Fatalf("%T %v and %T %v: got overlap %v, want %v", a, xfa, b, xfb, overlap, c != nil)
+		}
+	}
+}
+
+// ring is a circle with a type of its own, whose collider only reports
+// shapes that cross its boundary, like a hollow shape that GJK cannot
+// handle.
+type ring struct {
+	Circle
+}
+
+func collideRing(c *Collision, a Shape, xfa Transform, b Shape, xfb Transform, margin float64) bool {
+	r, circle := a.(*ring), b.(*Circle)
+	d := xfb.Mul(circle.Center).Sub(xfa.Mul(r.Center)).Length()
+	return d+circle.Radius > r.Radius && d < r.Radius+circle.Radius
+}
+
+func TestOverlapRegistered(t *testing.T) {
+	RegisterCollider((*ring)(nil), (*Circle)(nil), collideRing)
+	r := &ring{Circle{Radius: 2}}
+	circle := &Circle{Radius: 0.5}
+	origin := NewTransform(Point{}, 0)
+	tests := []struct {
+		name    string
+		xfb     Transform
+		overlap bool
+	}{
+		{"inside", NewTransform(Point{0.5, 0}, 0), false},
+		{"crossing", NewTransform(Point{2, 0}, 0), true},
+		{"outside", NewTransform(Point{3, 0}, 0), false},
+	}
+	for _, test := range tests {
+		if got := Overlap(r, origin, circle, test.xfb); got != test.overlap {
+			t.Errorf("%s: got overlap %v, want %v", test.name, got, test.overlap)
+		}
+		// The collider is also used for the reversed pair
+		if got := Overlap(circle, test.xfb, r, origin); got != test.overlap {
+			t.Errorf("%s reversed: got overlap %v, want %v", test.name, got, test.overlap)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package collide
+
+import (
+	"math"
+)
+
+// FeatureType represents the type of a feature.
+type FeatureType int
+
+const (
+	FeatureVertex FeatureType = iota // a vertex
+	FeatureEdge                      // an edge
+)
+
+// Feature identifies a vertex or an edge of a shape.
+// The edge with index i connects vertex i and vertex i+1.
+// The surface of a circle is its only edge.
+type Feature struct {
+	Type  FeatureType
+	Index int
+}
+
+// TestPoint reports whether the point p is inside the shape.
+func TestPoint(s Shape, xf Transform, p Point) bool {
+	switch s := s.(type) {
+	case *Circle:
+		return s.TestPoint(xf, p)
+	case *Polygon:
+		return s.TestPoint(xf, p)
+	case *Box:
+		return s.TestPoint(xf, p)
+	}
+	distance, _, _, _ := signedDistanceConvex(s, xf, p)
+	return distance <= 0
+}
+
+// ClosestPoint returns the point on the surface of the shape closest to the
+// point p, and the feature it lies on.
+func ClosestPoint(s Shape, xf Transform, p Point) (Point, Feature) {
+	switch s := s.(type) {
+	case *Circle:
+		return s.ClosestPoint(xf, p)
+	case *Polygon:
+		return s.ClosestPoint(xf, p)
+	case *Box:
+		return s.ClosestPoint(xf, p)
+	}
+	_, point, _, feature := signedDistanceConvex(s, xf, p)
+	return point, feature
+}
+
+// SignedDistance returns the distance from the point p to the surface of the
+// shape, which is negative if the point is inside the shape. It also returns
+// the outward surface normal at the closest point and the closest feature.
+func SignedDistance(s Shape, xf Transform, p Point) (float64, Point, Feature) {
+	switch s := s.(type) {
+	case *Circle:
+		return s.SignedDistance(xf, p)
+	case *Polygon:
+		return s.SignedDistance(xf, p)
+	case *Box:
+		return s.SignedDistance(xf, p)
+	}
+	distance, _, normal, feature := signedDistanceConvex(s, xf, p)
+	return distance, normal, feature
+}
+
+// pointShape is a circle of zero radius used to query points.
+// It must not be modified.
+var pointShape = &Circle{}
+
+// signedDistanceConvex returns the signed distance from the point p to an
+// arbitrary convex shape, along with the closest point, the surface normal
+// and the closest feature, using GJK and EPA.
+func signedDistanceConvex(s Shape, xf Transform, p Point) (float64, Point, Point, Feature) {
+	xfp := Transform{Position: p, Rotation: Rotation{Cos: 1}}
+	radius := s.GetRadius()
+
+	var simplex Simplex
+	simplex.GJK(s, xf, pointShape, xfp)
+	closest, _, distance := simplex.closestPoints(xf, xfp, 0, 0)
+	if distance > 0 {
+		normal := p.Sub(closest).Div(distance)
+		feature := featureOf(simplex.v[0], simplex.v[simplex.count-1])
+		return distance - radius, closest.Add(normal.Mul(radius)), normal, feature
+	}
+
+	penetration, edge := simplex.epa(s, xf, pointShape, xfp)
+	normal := penetration.Normal
+	closest = penetration.PointA.Add(normal.Mul(radius))
+	return -penetration.Depth - radius, closest, normal, featureOf(edge[0], edge[1])
+}
+
+// featureOf returns the feature of shape A spanned by two simplex vertices.
+func featureOf(v1, v2 vertex) Feature {
+	return featureBetween(v1.indexA, v2.indexA)
+}
+
+// featureBetween returns the feature spanned by the vertices i and j.
+func featureBetween(i, j int) Feature {
+	switch {
+	case i == j:
+		return Feature{FeatureVertex, i}
+	case i+1 == j:
+		return Feature{FeatureEdge, i}
+	case j+1 == i:
+		return Feature{FeatureEdge, j}
+	default:
+		// The edge wraps around to the first vertex
+		if i > j {
+			return Feature{FeatureEdge, i}
+		}
+		return Feature{FeatureEdge, j}
+	}
+}
+
+// TestPoint reports whether the point p is inside the circle.
+func (c *Circle) TestPoint(xf Transform, p Point) bool {
+	center := xf.Mul(c.Center)
+	return p.Sub(center).LengthSquared() <= c.Radius*c.Radius
+}
+
+// ClosestPoint returns the point on the circle closest to the point p.
+func (c *Circle) ClosestPoint(xf Transform, p Point) (Point, Feature) {
+	_, normal, feature := c.SignedDistance(xf, p)
+	return xf.Mul(c.Center).Add(normal.Mul(c.Radius)), feature
+}
+
+// SignedDistance returns the signed distance from the point p to the circle,
+// the outward surface normal at the closest point and the closest feature.
+func (c *Circle) SignedDistance(xf Transform, p Point) (float64, Point, Feature) {
+	d := p.Sub(xf.Mul(c.Center))
+	length := d.Length()
+
+	var normal Point
+	if length != 0 {
+		normal = d.Div(length)
+	} else {
+		// The point is at the center
+		// Choose arbitrary normal
+		normal = Point{1, 0}
+	}
+	return length - c.Radius, normal, Feature{FeatureEdge, 0}
+}
+
+// TestPoint reports whether the point p is inside the polygon.
+func (poly *Polygon) TestPoint(xf Transform, p Point) bool {
+	p = xf.MulT(p)
+	for i := range poly.Points {
+		if Dot(poly.Normals[i], p.Sub(poly.Points[i])) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ClosestPoint returns the point on the polygon closest to the point p.
+func (poly *Polygon) ClosestPoint(xf Transform, p Point) (Point, Feature) {
+	feature, point, _ := poly.closestFeature(xf.MulT(p))
+	return xf.Mul(point), feature
+}
+
+// SignedDistance returns the signed distance from the point p to the polygon,
+// the outward surface normal at the closest point and the closest feature.
+func (poly *Polygon) SignedDistance(xf Transform, p Point) (float64, Point, Feature) {
+	local := xf.MulT(p)
+	feature, point, separation := poly.closestFeature(local)
+
+	var normal Point
+	if feature.Type == FeatureVertex {
+		normal = local.Sub(point).Normalize()
+	} else {
+		normal = poly.Normals[feature.Index]
+	}
+	return separation, xf.Rotation.Mul(normal), feature
+}
+
+// TestPoint reports whether the point p is inside the box.
+func (b *Box) TestPoint(xf Transform, p Point) bool {
+	if xf.Rotation.isIdentity() {
+		d := p.Sub(xf.Position.Add(b.Center))
+		return math.Abs(d.X) <= b.Extents.X && math.Abs(d.Y) <= b.Extents.Y
+	}
+	var points [4]Point
+	poly := b.polygon(&points)
+	return poly.TestPoint(xf, p)
+}
+
+// ClosestPoint returns the point on the box closest to the point p.
+func (b *Box) ClosestPoint(xf Transform, p Point) (Point, Feature) {
+	var points [4]Point
+	poly := b.polygon(&points)
+	return poly.ClosestPoint(xf, p)
+}
+
+// SignedDistance returns the signed distance from the point p to the box,
+// the outward surface normal at the closest point and the closest feature.
+func (b *Box) SignedDistance(xf Transform, p Point) (float64, Point, Feature) {
+	var points [4]Point
+	poly := b.polygon(&points)
+	return poly.SignedDistance(xf, p)
+}
+
+// closestFeature returns the feature of the polygon closest to the point p,
+// the closest point on that feature and the signed distance from it.
+// All points are in the local space of the polygon.
+func (poly *Polygon) closestFeature(p Point) (Feature, Point, float64) {
+	// Find edge with minimum penetration
+	var normalIndex int
+	separation := -math.MaxFloat64
+	for i := range poly.Points {
+		s := Dot(poly.Normals[i], p.Sub(poly.Points[i]))
+		if s > separation {
+			separation = s
+			normalIndex = i
+		}
+	}
+
+	// If the point is inside the polygon
+	normal := poly.Normals[normalIndex]
+	if separation <= 0 {
+		return Feature{FeatureEdge, normalIndex}, p.Sub(normal.Mul(separation)), separation
+	}
+
+	// Grab face's vertices
+	i := normalIndex
+	j := i + 1
+	if j == len(poly.Points) {
+		j = 0
+	}
+	v1, v2 := poly.Points[i], poly.Points[j]
+
+	// Compute barycentric coordinates
+	u1 := Dot(p.Sub(v1), v2.Sub(v1))
+	u2 := Dot(p.Sub(v2), v1.Sub(v2))
+	if u1 <= 0 {
+		// Closest to v1
+		return Feature{FeatureVertex, i}, v1, p.Sub(v1).Length()
+	} else if u2 <= 0 {
+		// Closest to v2
+		return Feature{FeatureVertex, j}, v2, p.Sub(v2).Length()
+	} else {
+		// Closest to face
+		return Feature{FeatureEdge, i}, p.Sub(normal.Mul(separation)), separation
+	}
+}
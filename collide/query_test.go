@@ -0,0 +1,148 @@
+package collide
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSignedDistance(t *testing.T) {
+	square := Rectangle(Point{}, Point{1, 1})
+	tests := []struct {
+		name     string
+		shape    Shape
+		xf       Transform
+		point    Point
+		distance float64
+		closest  Point
+		normal   Point
+		feature  Feature
+	}{
+		{
+			name:     "outside circle",
+			shape:    &Circle{Radius: 1},
+			xf:       NewTransform(Point{1, 1}, 0),
+			point:    Point{4, 5},
+			distance: 4,
+			closest:  Point{1.6, 1.8},
+			normal:   Point{0.6, 0.8},
+			feature:  Feature{FeatureEdge, 0},
+		},
+		{
+			name:     "inside circle",
+			shape:    &Circle{Center: Point{0, 1}, Radius: 2},
+			xf:       NewTransform(Point{}, 0),
+			point:    Point{0, 0.5},
+			distance: -1.5,
+			closest:  Point{0, -1},
+			normal:   Point{0, -1},
+			feature:  Feature{FeatureEdge, 0},
+		},
+		{
+			name:     "outside polygon edge",
+			shape:    square,
+			xf:       NewTransform(Point{}, 0),
+			point:    Point{3, 0.5},
+			distance: 2,
+			closest:  Point{1, 0.5},
+			normal:   Point{1, 0},
+			feature:  Feature{FeatureEdge, 1},
+		},
+		{
+			name:     "outside polygon vertex",
+			shape:    square,
+			xf:       NewTransform(Point{}, 0),
+			point:    Point{-4, -5},
+			distance: 5,
+			closest:  Point{-1, -1},
+			normal:   Point{-0.6, -0.8},
+			feature:  Feature{FeatureVertex, 0},
+		},
+		{
+			name:     "inside polygon",
+			shape:    square,
+			xf:       NewTransform(Point{}, 0),
+			point:    Point{-0.25, 0.5},
+			distance: -0.5,
+			closest:  Point{-0.25, 1},
+			normal:   Point{0, 1},
+			feature:  Feature{FeatureEdge, 2},
+		},
+		{
+			name:     "rotated polygon",
+			shape:    square,
+			xf:       NewTransform(Point{2, 0}, math.Pi/2),
+			point:    Point{2, 3},
+			distance: 2,
+			closest:  Point{2, 1},
+			normal:   Point{0, 1},
+			feature:  Feature{FeatureEdge, 1},
+		},
+		{
+			name:     "box",
+			shape:    &Box{Center: Point{1, 0}, Extents: Point{1, 1}},
+			xf:       NewTransform(Point{}, 0),
+			point:    Point{1.5, -3},
+			distance: 2,
+			closest:  Point{1.5, -1},
+			normal:   Point{0, -1},
+			feature:  Feature{FeatureEdge, 0},
+		},
+	}
+	for _, test := range tests {
+		distance, normal, feature := SignedDistance(test.shape, test.xf, test.point)
+		if !approxEqual(distance, test.distance) {
+			t.Errorf("%s: got distance %v, want %v", test.name, distance, test.distance)
+		}
+		if !approxEqualPoint(normal, test.normal) {
+			t.Errorf("%s: got normal %v, want %v", test.name, normal, test.normal)
+		}
+		if feature != test.feature {
+			t.Errorf("%s: got feature %v, want %v", test.name, feature, test.feature)
+		}
+
+		closest, feature := ClosestPoint(test.shape, test.xf, test.point)
+		if !approxEqualPoint(closest, test.closest) {
+			t.Errorf("%s: got closest point %v, want %v", test.name, closest, test.closest)
+		}
+		if feature != test.feature {
+			t.Errorf("%s: got closest feature %v, want %v", test.name, feature, test.feature)
+		}
+
+		inside := TestPoint(test.shape, test.xf, test.point)
+		if inside != (test.distance <= 0) {
+			t.Errorf("%s: got inside %v, want %v", test.name, inside, !inside)
+		}
+	}
+}
+
+func TestSignedDistanceConvex(t *testing.T) {
+	// The paths for arbitrary convex shapes agree with those for polygons
+	polygon := NewPolygon(Point{-1, -1}, Point{1, -1}, Point{1.5, 0.5}, Point{0, 1.5})
+	points := []Point{
+		{3, 0}, {0, 4}, {-3, -3}, {2, 2}, {0.1, 0.2}, {0, -0.8}, {1.2, 0.4},
+	}
+	for _, xf := range []Transform{NewTransform(Point{}, 0), NewTransform(Point{0.5, -0.5}, 1)} {
+		for _, p := range points {
+			wantDistance, wantNormal, wantFeature := polygon.SignedDistance(xf, p)
+			distance, normal, feature := SignedDistance(hull{polygon}, xf, p)
+			if !approxEqual(distance, wantDistance) {
+				t.Errorf("%v: got distance %v, want %v", p, distance, wantDistance)
+			}
+			if !approxEqualPoint(normal, wantNormal) {
+				t.Errorf("%v: got normal %v, want %v", p, normal, wantNormal)
+			}
+			if feature != wantFeature {
+				t.Errorf("%v: got feature %v, want %v", p, feature, wantFeature)
+			}
+
+			wantClosest, _ := polygon.ClosestPoint(xf, p)
+			closest, _ := ClosestPoint(hull{polygon}, xf, p)
+			if !approxEqualPoint(closest, wantClosest) {
+				t.Errorf("%v: got closest point %v, want %v", p, closest, wantClosest)
+			}
+			if TestPoint(hull{polygon}, xf, p) != polygon.TestPoint(xf, p) {
+				t.Errorf("%v: got inside %v, want %v", p, !polygon.TestPoint(xf, p), polygon.TestPoint(xf, p))
+			}
+		}
+	}
+}
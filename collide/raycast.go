@@ -0,0 +1,159 @@
+package collide
+
+import (
+	"math"
+)
+
+// RayCastInput is the input to RayCast.
+type RayCastInput struct {
+	Origin      Point     // ray origin in world space
+	Direction   Point     // ray direction, scaled by the length of the ray
+	MaxFraction float64   // maximum fraction of the direction to cast
+	Settings    *Settings // settings, or nil for MeterSettings
+}
+
+// RayCastOutput is the output of RayCast.
+type RayCastOutput struct {
+	Point    Point   // hit point in world space
+	Normal   Point   // surface normal at the hit point
+	Fraction float64 // fraction of the direction at the hit point
+	Hit      bool    // whether the ray hit the shape
+}
+
+// RayCast casts a ray against a shape. Rays that start inside a shape
+// do not hit it.
+func RayCast(s Shape, xf Transform, input *RayCastInput) RayCastOutput {
+	switch s := s.(type) {
+	case *Circle:
+		return s.RayCast(xf, input)
+	case *Polygon:
+		return s.RayCast(xf, input)
+	case *Box:
+		return s.RayCast(xf, input)
+	}
+	return rayCastConvex(s, xf, input)
+}
+
+// rayCastConvex casts a ray against an arbitrary convex shape
+// by casting a point using the GJK raycast algorithm.
+// The result is only accurate to within the LinearSlop of the settings.
+func rayCastConvex(s Shape, xf Transform, input *RayCastInput) RayCastOutput {
+	output := ShapeCast(&ShapeCastInput{
+		A:            s,
+		B:            pointShape,
+		TransformA:   xf,
+		TransformB:   Transform{Position: input.Origin, Rotation: Rotation{Cos: 1}},
+		TranslationB: input.Direction.Mul(input.MaxFraction),
+		Settings:     input.Settings,
+	})
+	if !output.Hit || output.Fraction == 0 {
+		// The ray starts inside or on the shape
+		return RayCastOutput{}
+	}
+	return RayCastOutput{
+		Point:    output.Point,
+		Normal:   output.Normal,
+		Fraction: output.Fraction * input.MaxFraction,
+		Hit:      true,
+	}
+}
+
+// RayCast casts a ray against the circle.
+func (c *Circle) RayCast(xf Transform, input *RayCastInput) RayCastOutput {
+	// Shift ray so circle center is the origin
+	center := xf.Mul(c.Center)
+	s := input.Origin.Sub(center)
+	b := Dot(s, s) - c.Radius*c.Radius
+	if b < 0 {
+		// Ray starts inside the circle
+		return RayCastOutput{}
+	}
+
+	// Solve quadratic equation
+	r := input.Direction
+	k := Dot(s, r)
+	rr := Dot(r, r)
+	sigma := k*k - rr*b
+
+	// Check for negative discriminant and short segment
+	if sigma < 0 || rr == 0 {
+		return RayCastOutput{}
+	}
+
+	// Find the point of intersection of the line with the circle
+	a := -(k + math.Sqrt(sigma))
+
+	// Is the intersection point on the segment?
+	if a < 0 || a > input.MaxFraction*rr {
+		return RayCastOutput{}
+	}
+
+	fraction := a / rr
+	normal := s.Add(r.Mul(fraction)).Normalize()
+	return RayCastOutput{
+		Point:    center.Add(normal.Mul(c.Radius)),
+		Normal:   normal,
+		Fraction: fraction,
+		Hit:      true,
+	}
+}
+
+// RayCast casts a ray against the polygon.
+func (p *Polygon) RayCast(xf Transform, input *RayCastInput) RayCastOutput {
+	// Put the ray into the polygon's frame of reference
+	origin := xf.MulT(input.Origin)
+	dir := xf.Rotation.MulT(input.Direction)
+
+	lower, upper := 0.0, input.MaxFraction
+	index := -1
+	for i := range p.Points {
+		// p = origin + a * dir
+		// dot(normal, p - v) = 0
+		// dot(normal, origin - v) + a * dot(normal, dir) = 0
+		numerator := Dot(p.Normals[i], p.Points[i].Sub(origin))
+		denominator := Dot(p.Normals[i], dir)
+
+		if denominator == 0 {
+			if numerator < 0 {
+				// Ray is parallel to and outside of this edge
+				return RayCastOutput{}
+			}
+		} else {
+			// Note: we want this predicate without division:
+			// lower < numerator / denominator, where denominator < 0
+			// Since denominator < 0, we have to flip the inequality:
+			// lower < numerator / denominator <==> denominator * lower > numerator.
+			if denominator < 0 && numerator < lower*denominator {
+				// Increase lower. The segment enters this half-space.
+				lower = numerator / denominator
+				index = i
+			} else if denominator > 0 && numerator < upper*denominator {
+				// Decrease upper. The segment exits this half-space.
+				upper = numerator / denominator
+			}
+		}
+
+		if upper < lower {
+			return RayCastOutput{}
+		}
+	}
+
+	if index < 0 {
+		// Ray starts inside the polygon
+		return RayCastOutput{}
+	}
+
+	return RayCastOutput{
+		Point:    input.Origin.Add(input.Direction.Mul(lower)),
+		Normal:   xf.Rotation.Mul(p.Normals[index]),
+		Fraction: lower,
+		Hit:      true,
+	}
+}
+
+// RayCast casts a ray against the box.
+func (b *Box) RayCast(xf Transform, input *RayCastInput) RayCastOutput {
+	var points [4]Point
+	poly := b.polygon(&points)
+	return poly.RayCast(xf, input)
+}
@@ -0,0 +1,162 @@
+package collide
+
+import (
+	"math"
+	"testing"
+)
+
+// hull is a polygon that is not recognized by the type switches of this
+// package, to test the paths used for arbitrary convex shapes.
+type hull struct {
+	*Polygon
+}
+
+func TestRayCast(t *testing.T) {
+	square := Rectangle(Point{}, Point{1, 1})
+	tests := []struct {
+		name     string
+		shape    Shape
+		xf       Transform
+		input    RayCastInput
+		hit      bool
+		fraction float64
+		normal   Point
+	}{
+		{
+			name:     "circle",
+			shape:    &Circle{Radius: 1},
+			xf:       NewTransform(Point{5, 0}, 0),
+			input:    RayCastInput{Origin: Point{}, Direction: Point{10, 0}, MaxFraction: 1},
+			hit:      true,
+			fraction: 0.4,
+			normal:   Point{-1, 0},
+		},
+		{
+			name:     "offset circle",
+			shape:    &Circle{Center: Point{0, 1}, Radius: 1},
+			xf:       NewTransform(Point{0, -6}, 0),
+			input:    RayCastInput{Origin: Point{}, Direction: Point{0, -1}, MaxFraction: 10},
+			hit:      true,
+			fraction: 4,
+			normal:   Point{0, 1},
+		},
+		{
+			name:  "circle beyond max fraction",
+			shape: &Circle{Radius: 1},
+			xf:    NewTransform(Point{5, 0}, 0),
+			input: RayCastInput{Origin: Point{}, Direction: Point{1, 0}, MaxFraction: 3},
+		},
+		{
+			name:  "circle missed",
+			shape: &Circle{Radius: 1},
+			xf:    NewTransform(Point{5, 2}, 0),
+			input: RayCastInput{Origin: Point{}, Direction: Point{1, 0}, MaxFraction: 10},
+		},
+		{
+			name:  "inside circle",
+			shape: &Circle{Radius: 1},
+			xf:    NewTransform(Point{}, 0),
+			input: RayCastInput{Origin: Point{0.5, 0}, Direction: Point{1, 0}, MaxFraction: 10},
+		},
+		{
+			name:     "polygon",
+			shape:    square,
+			xf:       NewTransform(Point{0, 4}, 0),
+			input:    RayCastInput{Origin: Point{0.5, 0}, Direction: Point{0, 1}, MaxFraction: 10},
+			hit:      true,
+			fraction: 3,
+			normal:   Point{0, -1},
+		},
+		{
+			name:     "rotated polygon",
+			shape:    square,
+			xf:       NewTransform(Point{4, 0}, math.Pi/4),
+			input:    RayCastInput{Origin: Point{0, 0.2}, Direction: Point{2, 0}, MaxFraction: 10},
+			hit:      true,
+			fraction: (4.2 - math.Sqrt2) / 2,
+			normal:   Point{-math.Sqrt2 / 2, math.Sqrt2 / 2},
+		},
+		{
+			name:  "polygon missed",
+			shape: square,
+			xf:    NewTransform(Point{0, 4}, 0),
+			input: RayCastInput{Origin: Point{1.5, 0}, Direction: Point{0, 1}, MaxFraction: 10},
+		},
+		{
+			name:  "parallel to polygon",
+			shape: square,
+			xf:    NewTransform(Point{4, 0}, 0),
+			input: RayCastInput{Origin: Point{0, 1.5}, Direction: Point{1, 0}, MaxFraction: 10},
+		},
+		{
+			name:  "inside polygon",
+			shape: square,
+			xf:    NewTransform(Point{}, 0),
+			input: RayCastInput{Origin: Point{}, Direction: Point{1, 0}, MaxFraction: 10},
+		},
+		{
+			name:     "box",
+			shape:    &Box{Center: Point{1, 0}, Extents: Point{1, 2}},
+			xf:       NewTransform(Point{-5, 0}, 0),
+			input:    RayCastInput{Origin: Point{}, Direction: Point{-1, 0}, MaxFraction: 10},
+			hit:      true,
+			fraction: 3,
+			normal:   Point{1, 0},
+		},
+	}
+	for _, test := range tests {
+		output := RayCast(test.shape, test.xf, &test.input)
+		if output.Hit != test.hit {
+			t.Errorf("%s: got hit %v, want %v", test.name, output.Hit, test.hit)
+			continue
+		}
+		if !output.Hit {
+			continue
+		}
+		if !approxEqual(output.Fraction, test.fraction) {
+			t.Errorf("%s: got fraction %v, want %v", test.name, output.Fraction, test.fraction)
+		}
+		if !approxEqualPoint(output.Normal, test.normal) {
+			t.Errorf("%s: got normal %v, want %v", test.name, output.Normal, test.normal)
+		}
+		point := test.input.Origin.Add(test.input.Direction.Mul(test.fraction))
+		if !approxEqualPoint(output.Point, point) {
+			t.Errorf("%s: got point %v, want %v", test.name, output.Point, point)
+		}
+	}
+}
+
+func TestRayCastConvex(t *testing.T) {
+	// The GJK raycast stops short of the surface by up to one and a half
+	// LinearSlop, and agrees with the polygon raycast otherwise
+	slop := MeterSettings().LinearSlop
+	polygon := NewPolygon(Point{-1, -1}, Point{1, -1}, Point{1.5, 0.5}, Point{0, 1.5})
+	inputs := []RayCastInput{
+		{Origin: Point{-5, 0}, Direction: Point{1, 0}, MaxFraction: 10},
+		{Origin: Point{0, 5}, Direction: Point{0.1, -1}, MaxFraction: 10},
+		{Origin: Point{3, 3}, Direction: Point{-1, -1}, MaxFraction: 10},
+		{Origin: Point{-5, -5}, Direction: Point{1, 0}, MaxFraction: 10},
+		{Origin: Point{-5, 0}, Direction: Point{1, 0}, MaxFraction: 2},
+		{Origin: Point{}, Direction: Point{1, 0}, MaxFraction: 10},
+	}
+	for _, xf := range []Transform{NewTransform(Point{}, 0), NewTransform(Point{0.5, -0.5}, 1)} {
+		for _, input := range inputs {
+			want := RayCast(polygon, xf, &input)
+			got := RayCast(hull{polygon}, xf, &input)
+			if got.Hit != want.Hit {
+				t.Errorf("%v: got hit %v, want %v", input, got.Hit, want.Hit)
+				continue
+			}
+			if !got.Hit {
+				continue
+			}
+			length := input.Direction.Length()
+			if math.Abs(got.Fraction-want.Fraction)*length > 2*slop {
+				t.Errorf("%v: got fraction %v, want %v", input, got.Fraction, want.Fraction)
+			}
+			if Dot(got.Normal, want.Normal) < 1-1e-6 {
+				t.Errorf("%v: got normal %v, want %v", input, got.Normal, want.Normal)
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package collide
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// A Collider calculates a collision for two shapes of specific types,
+// including speculative contacts for shapes separated by less than margin.
+// It stores the collision in c, which may hold a previous collision,
+// and reports whether one was found.
+// The collision normal must point from a to b.
+type Collider func(c *Collision, a Shape, xfa Transform, b Shape, xfb Transform, margin float64) bool
+
+// colliderEntry is a collider registered for a pair of shape types.
+type colliderEntry struct {
+	a, b reflect.Type
+	fn   Collider
+}
+
+// The registry is small, so it is kept in a slice which is faster to search
+// than a map.
+var (
+	registryMu sync.Mutex   // serializes writers
+	registry   atomic.Value // []colliderEntry
+)
+
+func init() {
+	registry.Store([]colliderEntry(nil))
+
+	RegisterCollider((*Circle)(nil), (*Circle)(nil),
+		func(c *Collision, a Shape, xfa Transform, b Shape, xfb Transform, margin float64) bool {
+			return collideCircles(c, a.(*Circle), xfa, b.(*Circle), xfb, margin)
+		})
+	RegisterCollider((*Polygon)(nil), (*Circle)(nil),
+		func(c *Collision, a Shape, xfa Transform, b Shape, xfb Transform, margin float64) bool {
+			return collidePolygonAndCircle(c, a.(*Polygon), xfa, b.(*Circle), xfb, margin)
+		})
+	RegisterCollider((*Polygon)(nil), (*Polygon)(nil),
+		func(c *Collision, a Shape, xfa Transform, b Shape, xfb Transform, margin float64) bool {
+			return collidePolygons(c, a.(*Polygon), xfa, b.(*Polygon), xfb, margin)
+		})
+	RegisterCollider((*Box)(nil), (*Box)(nil),
+		func(c *Collision, a Shape, xfa Transform, b Shape, xfb Transform, margin float64) bool {
+			return collideBoxes(c, a.(*Box), xfa, b.(*Box), xfb, margin)
+		})
+	RegisterCollider((*Box)(nil), (*Circle)(nil),
+		func(c *Collision, a Shape, xfa Transform, b Shape, xfb Transform, margin float64) bool {
+			return collideBoxAndCircle(c, a.(*Box), xfa, b.(*Circle), xfb, margin)
+		})
+	RegisterCollider((*Box)(nil), (*Polygon)(nil),
+		func(c *Collision, a Shape, xfa Transform, b Shape, xfb Transform, margin float64) bool {
+			return collideBoxAndPolygon(c, a.(*Box), xfa, b.(*Polygon), xfb, margin)
+		})
+}
+
+// RegisterCollider registers the collider used by Collide for shapes with the
+// same types as a and b, replacing any previously registered collider.
+// The values of a and b are only used for their types, so typed nil pointers
+// may be passed. The collider is also used for the reversed pair of types,
+// with the collision normal flipped, unless a collider is registered for
+// the reversed pair as well.
+//
+// It is safe to call RegisterCollider concurrently with Collide.
+func RegisterCollider(a, b Shape, fn Collider) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	entry := colliderEntry{reflect.TypeOf(a), reflect.TypeOf(b), fn}
+
+	// Copy the registry so that readers never need to lock
+	old := registry.Load().([]colliderEntry)
+	entries := make([]colliderEntry, 0, len(old)+1)
+	for _, e := range old {
+		if e.a != entry.a || e.b != entry.b {
+			entries = append(entries, e)
+		}
+	}
+	entries = append(entries, entry)
+	registry.Store(entries)
+}
+
+// lookupCollider returns the collider registered for the types of a and b.
+// It reports whether the collider was registered for the reversed pair of
+// types, in which case the shapes must be swapped and the collision flipped.
+func lookupCollider(a, b Shape) (Collider, bool) {
+	entries := registry.Load().([]colliderEntry)
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	for i := range entries {
+		if entries[i].a == ta && entries[i].b == tb {
+			return entries[i].fn, false
+		}
+	}
+	for i := range entries {
+		if entries[i].a == tb && entries[i].b == ta {
+			return entries[i].fn, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,86 @@
+package collide
+
+import (
+	"testing"
+)
+
+// marker is a circle with a type of its own, so that tests can register
+// colliders for it without affecting other tests.
+type marker struct {
+	Circle
+}
+
+// normalCollider returns a collider that reports a collision with the
+// given normal and records the margin it was called with.
+func normalCollider(normal Point, margin *float64) Collider {
+	return func(c *Collision, a Shape, xfa Transform, b Shape, xfb Transform, m float64) bool {
+		*margin = m
+		*c = Collision{Normal: normal}
+		c.addPoint(Point{}, 0)
+		return true
+	}
+}
+
+func TestRegisterCollider(t *testing.T) {
+	m := &marker{Circle{Radius: 1}}
+	circle := &Circle{Radius: 1}
+	xfa := NewTransform(Point{}, 0)
+	xfb := NewTransform(Point{10, 0}, 0)
+
+	// Without a collider, the shapes are collided with GJK and EPA
+	if c := Collide(m, xfa, circle, xfb); c != nil {
+		t.Fatalf("got collision %v for separated shapes", c)
+	}
+
+	var margin float64
+	RegisterCollider((*marker)(nil), (*Circle)(nil), normalCollider(Point{0, 1}, &margin))
+	if c := CollideSpeculative(m, xfa, circle, xfb, 0.5); c == nil || c.Normal != (Point{0, 1}) {
+		t.Errorf("got collision %v, want normal (0, 1)", c)
+	}
+	if margin != 0.5 {
+		t.Errorf("got margin %v, want 0.5", margin)
+	}
+
+	// The collider is used for the reversed pair with the normal flipped
+	if c := Collide(circle, xfa, m, xfb); c == nil || c.Normal != (Point{0, -1}) {
+		t.Errorf("got reversed collision %v, want normal (0, -1)", c)
+	}
+	if margin != 0 {
+		t.Errorf("got margin %v, want 0", margin)
+	}
+
+	// Unless a collider is registered for the reversed pair as well
+	RegisterCollider((*Circle)(nil), (*marker)(nil), normalCollider(Point{1, 0}, &margin))
+	if c := Collide(circle, xfa, m, xfb); c == nil || c.Normal != (Point{1, 0}) {
+		t.Errorf("got reversed collision %v, want normal (1, 0)", c)
+	}
+
+	// Registering a collider again replaces it
+	RegisterCollider((*marker)(nil), (*Circle)(nil), normalCollider(Point{-1, 0}, &margin))
+	var c Collision
+	if !CollideTo(&c, m, xfa, circle, xfb) || c.Normal != (Point{-1, 0}) {
+		t.Errorf("got collision %v, want normal (-1, 0)", c)
+	}
+}
+
+func TestLookupCollider(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  Shape
+		found bool
+		flip  bool
+	}{
+		{"circles", &Circle{}, &Circle{}, true, false},
+		{"polygon and circle", &Polygon{}, &Circle{}, true, false},
+		{"circle and polygon", &Circle{}, &Polygon{}, true, true},
+		{"box and polygon", &Box{}, &Polygon{}, true, false},
+		{"polygon and box", &Polygon{}, &Box{}, true, true},
+		{"convex shapes", hull{}, &Circle{}, false, false},
+	}
+	for _, test := range tests {
+		fn, flip := lookupCollider(test.a, test.b)
+		if (fn != nil) != test.found || flip != test.flip {
+			t.Errorf("%s: got found %v, flip %v, want %v, %v", test.name, fn != nil, flip, test.found, test.flip)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package collide
+
+import (
+	"math"
+)
+
+// endpoint is the lower or upper bound of a proxy on an axis.
+type endpoint struct {
+	value float64
+	id    int
+	max   bool
+}
+
+// less reports whether the endpoint sorts before e. Lower bounds sort before
+// upper bounds of the same value, so that touching proxies overlap.
+func (p endpoint) less(e endpoint) bool {
+	return p.value < e.value || p.value == e.value && !p.max && e.max
+}
+
+// sapProxy is a bounding box in a SweepAndPrune.
+type sapProxy struct {
+	aabb     AABB
+	userData interface{}
+
+	// min and max are the indices of the endpoints on each axis
+	min, max [2]int
+	inUse    bool
+}
+
+// pairKey identifies a pair of proxies, with a less than b.
+type pairKey struct {
+	a, b int
+}
+
+// makePairKey returns the key of the pair of proxies a and b.
+func makePairKey(a, b int) pairKey {
+	if a > b {
+		a, b = b, a
+	}
+	return pairKey{a, b}
+}
+
+// PairEvent reports that the bounding boxes of a pair of proxies started or
+// stopped overlapping.
+type PairEvent struct {
+	IDA, IDB int  // ids of the proxies, with IDA less than IDB
+	Added    bool // whether the bounding boxes started overlapping
+}
+
+// A SweepAndPrune is a broadphase that keeps the bounds of the proxies sorted
+// on each axis. It is updated with insertion sort, which takes time
+// proportional to the number of endpoints that the moving bounds pass over,
+// so it is fast when the proxies barely move between updates. When proxies
+// move far, the sort takes up to quadratic time and a HashGrid or
+// DynamicTree is faster. Pairs of proxies are found as the endpoints pass
+// over each other, and recorded as events.
+//
+// Proxies are identified by the integer returned by Insert, which stays valid
+// until the proxy is removed. Ids of removed proxies are reused.
+type SweepAndPrune struct {
+	axes    [2][]endpoint
+	proxies []sapProxy
+	free    []int
+
+	// pairs maps the overlapping pairs to their index in pairList
+	pairs    map[pairKey]int
+	pairList []pairKey
+	events   []PairEvent
+}
+
+// NewSweepAndPrune returns an empty sweep and prune broadphase.
+func NewSweepAndPrune() *SweepAndPrune {
+	return &SweepAndPrune{
+		pairs: make(map[pairKey]int),
+	}
+}
+
+// bound returns the value of a bound of the bounding box on an axis.
+func bound(aabb *AABB, axis int, max bool) float64 {
+	p := aabb.Min
+	if max {
+		p = aabb.Max
+	}
+	if axis == 0 {
+		return p.X
+	}
+	return p.Y
+}
+
+// checkAABB panics if the bounding box is invalid or not finite, since
+// infinite and NaN bounds cannot be sorted into place.
+func checkAABB(aabb *AABB) {
+	if !aabb.IsValid() ||
+		math.IsInf(aabb.Min.X, 0) || math.IsInf(aabb.Min.Y, 0) ||
+		math.IsInf(aabb.Max.X, 0) || math.IsInf(aabb.Max.Y, 0) {
+		panic("collide: invalid AABB")
+	}
+}
+
+// Insert adds a proxy with the given bounding box and returns its id.
+// A pair event is recorded for each proxy that it overlaps.
+// The bounding box must be valid and finite.
+func (s *SweepAndPrune) Insert(aabb AABB, userData interface{}) int {
+	checkAABB(&aabb)
+
+	var id int
+	if n := len(s.free); n > 0 {
+		id = s.free[n-1]
+		s.free = s.free[:n-1]
+	} else {
+		s.proxies = append(s.proxies, sapProxy{})
+		id = len(s.proxies) - 1
+	}
+
+	p := &s.proxies[id]
+	p.aabb = aabb
+	p.userData = userData
+	p.inUse = true
+
+	// Sort the endpoints into place from the end of the axes, the upper
+	// bound first so that the lower bound finds the overlaps as it passes
+	// over upper bounds
+	for axis := range s.axes {
+		s.axes[axis] = append(s.axes[axis], endpoint{bound(&aabb, axis, true), id, true})
+		s.siftDown(axis, len(s.axes[axis])-1)
+		s.axes[axis] = append(s.axes[axis], endpoint{bound(&aabb, axis, false), id, false})
+		s.siftDown(axis, len(s.axes[axis])-1)
+	}
+	return id
+}
+
+// Update changes the bounding box of a proxy. Pair events are recorded for
+// the proxies that it starts or stops overlapping.
+// The bounding box must be valid and finite.
+func (s *SweepAndPrune) Update(id int, aabb AABB) {
+	p := s.proxy(id)
+	checkAABB(&aabb)
+	old := p.aabb
+	p.aabb = aabb
+	for axis := range s.axes {
+		s.axes[axis][p.min[axis]].value = bound(&aabb, axis, false)
+		s.axes[axis][p.max[axis]].value = bound(&aabb, axis, true)
+
+		// Sort the leading endpoint first, so that neither endpoint is
+		// blocked by the other one at its old position
+		if bound(&aabb, axis, true) > bound(&old, axis, true) {
+			s.sift(axis, p.max[axis])
+			s.sift(axis, p.min[axis])
+		} else {
+			s.sift(axis, p.min[axis])
+			s.sift(axis, p.max[axis])
+		}
+	}
+}
+
+// Remove removes a proxy. A pair event is recorded for each proxy that it
+// overlapped.
+func (s *SweepAndPrune) Remove(id int) {
+	p := s.proxy(id)
+
+	// Move the proxy past the end of the axes, which separates it from
+	// every other proxy, then drop its endpoints
+	inf := math.Inf(1)
+	p.aabb = AABB{Point{inf, inf}, Point{inf, inf}}
+	for axis := range s.axes {
+		s.axes[axis][p.min[axis]].value = inf
+		s.axes[axis][p.max[axis]].value = inf
+		s.siftUp(axis, p.max[axis])
+		s.siftUp(axis, p.min[axis])
+		s.axes[axis] = s.axes[axis][:len(s.axes[axis])-2]
+	}
+	*p = sapProxy{}
+	s.free = append(s.free, id)
+}
+
+// proxy returns the proxy with the given id.
+func (s *SweepAndPrune) proxy(id int) *sapProxy {
+	if id < 0 || id >= len(s.proxies) || !s.proxies[id].inUse {
+		panic("collide: invalid proxy")
+	}
+	return &s.proxies[id]
+}
+
+// UserData returns the user data of a proxy.
+func (s *SweepAndPrune) UserData(id int) interface{} {
+	return s.proxy(id).userData
+}
+
+// AABB returns the bounding box of a proxy.
+func (s *SweepAndPrune) AABB(id int) AABB {
+	return s.proxy(id).aabb
+}
+
+// ProxyCount returns the number of proxies.
+func (s *SweepAndPrune) ProxyCount() int {
+	return len(s.proxies) - len(s.free)
+}
+
+// sift moves the endpoint at index i in whichever direction sorts it.
+func (s *SweepAndPrune) sift(axis, i int) {
+	if i > 0 && s.axes[axis][i].less(s.axes[axis][i-1]) {
+		s.siftDown(axis, i)
+	} else {
+		s.siftUp(axis, i)
+	}
+}
+
+// siftDown moves the endpoint at index i towards the start of the axis
+// until it is sorted.
+func (s *SweepAndPrune) siftDown(axis, i int) {
+	ep := s.axes[axis]
+	e := ep[i]
+	for i > 0 && e.less(ep[i-1]) {
+		other := ep[i-1]
+		if e.max != other.max && e.id != other.id {
+			if e.max {
+				// The upper bound passed below the lower bound
+				s.removePair(e.id, other.id)
+			} else {
+				// The lower bound passed below the upper bound
+				s.addPair(e.id, other.id)
+			}
+		}
+		ep[i] = other
+		s.setIndex(axis, other, i)
+		i--
+	}
+	ep[i] = e
+	s.setIndex(axis, e, i)
+}
+
+// siftUp moves the endpoint at index i towards the end of the axis until
+// it is sorted.
+func (s *SweepAndPrune) siftUp(axis, i int) {
+	ep := s.axes[axis]
+	e := ep[i]
+	for i < len(ep)-1 && ep[i+1].less(e) {
+		other := ep[i+1]
+		if e.max != other.max && e.id != other.id {
+			if e.max {
+				// The upper bound passed above the lower bound
+				s.addPair(e.id, other.id)
+			} else {
+				// The lower bound passed above the upper bound
+				s.removePair(e.id, other.id)
+			}
+		}
+		ep[i] = other
+		s.setIndex(axis, other, i)
+		i++
+	}
+	ep[i] = e
+	s.setIndex(axis, e, i)
+}
+
+// setIndex records the index of an endpoint in its proxy.
+func (s *SweepAndPrune) setIndex(axis int, e endpoint, i int) {
+	if e.max {
+		s.proxies[e.id].max[axis] = i
+	} else {
+		s.proxies[e.id].min[axis] = i
+	}
+}
+
+// addPair records a pair if the proxies overlap and were not overlapping.
+func (s *SweepAndPrune) addPair(a, b int) {
+	if !s.proxies[a].aabb.Overlaps(s.proxies[b].aabb) {
+		return
+	}
+	key := makePairKey(a, b)
+	if _, ok := s.pairs[key]; ok {
+		return
+	}
+	s.pairs[key] = len(s.pairList)
+	s.pairList = append(s.pairList, key)
+	s.events = append(s.events, PairEvent{key.a, key.b, true})
+}
+
+// removePair removes a pair if the proxies were overlapping.
+func (s *SweepAndPrune) removePair(a, b int) {
+	key := makePairKey(a, b)
+	i, ok := s.pairs[key]
+	if !ok {
+		return
+	}
+	last := s.pairList[len(s.pairList)-1]
+	s.pairList[i] = last
+	s.pairs[last] = i
+	s.pairList = s.pairList[:len(s.pairList)-1]
+	delete(s.pairs, key)
+	s.events = append(s.events, PairEvent{key.a, key.b, false})
+}
+
+// Events returns the pair events recorded since the last call to
+// ClearEvents, in the order in which they occurred. A pair may be added and
+// removed by successive updates.
+//
+// The returned slice shares memory with the broadphase. It is only valid
+// until the next call to ClearEvents, which reuses it for later events.
+func (s *SweepAndPrune) Events() []PairEvent {
+	return s.events
+}
+
+// ClearEvents discards the recorded pair events.
+func (s *SweepAndPrune) ClearEvents() {
+	s.events = s.events[:0]
+}
+
+// PairCount returns the number of pairs of overlapping proxies.
+func (s *SweepAndPrune) PairCount() int {
+	return len(s.pairList)
+}
+
+// QueryPairs calls callback with the ids of each pair of overlapping
+// proxies, with idA less than idB. The query stops if callback returns
+// false. The broadphase must not be modified by callback.
+func (s *SweepAndPrune) QueryPairs(callback func(idA, idB int) bool) {
+	for _, key := range s.pairList {
+		if !callback(key.a, key.b) {
+			return
+		}
+	}
+}
+
+// Query calls callback with the id of each proxy whose bounding box overlaps
+// aabb. The query stops if callback returns false.
+// The broadphase must not be modified by callback.
+//
+// Any proxy that starts before the query on the x axis may overlap it, so
+// Query scans the lower bounds from the start of the axis and takes time
+// linear in the number of proxies. Use a DynamicTree or HashGrid for
+// frequent queries.
+func (s *SweepAndPrune) Query(aabb AABB, callback func(id int) bool) {
+	// Scan the lower bounds on the x axis up to the end of the query
+	for _, e := range s.axes[0] {
+		if e.value > aabb.Max.X {
+			return
+		}
+		if e.max || !s.proxies[e.id].aabb.Overlaps(aabb) {
+			continue
+		}
+		if !callback(e.id) {
+			return
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package collide
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSweepAndPrune(t *testing.T) {
+	// Random updates keep the pairs in agreement with brute force
+	r := rand.New(rand.NewSource(1))
+	s := NewSweepAndPrune()
+	boxes := map[int]AABB{}
+
+	// Integer coordinates produce many equal endpoints
+	randomBox := func() AABB {
+		p := Point{float64(r.Intn(60)), float64(r.Intn(60))}
+		return AABB{p, p.Add(Point{float64(r.Intn(5)), float64(r.Intn(5))})}
+	}
+
+	// Replaying the events reproduces the pairs
+	replay := map[pairKey]bool{}
+	for step := 0; step < 2000; step++ {
+		switch k := r.Intn(10); {
+		case k < 3 || len(boxes) < 5:
+			aabb := randomBox()
+			boxes[s.Insert(aabb, step)] = aabb
+		case k < 4:
+			for id := range boxes {
+				s.Remove(id)
+				delete(boxes, id)
+				break
+			}
+		default:
+			for id, aabb := range boxes {
+				if r.Intn(2) == 0 {
+					aabb = randomBox()
+				} else {
+					d := Point{float64(r.Intn(5) - 2), float64(r.Intn(5) - 2)}
+					aabb = AABB{aabb.Min.Add(d), aabb.Max.Add(d)}
+				}
+				s.Update(id, aabb)
+				boxes[id] = aabb
+				break
+			}
+		}
+		for _, e := range s.Events() {
+			key := pairKey{e.IDA, e.IDB}
+			if e.IDA >= e.IDB || replay[key] == e.Added {
+				t.Fatalf("step %d: got unexpected event %v", step, e)
+			}
+			if e.Added {
+				replay[key] = true
+			} else {
+				delete(replay, key)
+			}
+		}
+		s.ClearEvents()
+		if s.ProxyCount() != len(boxes) {
+			t.Fatalf("step %d: got %d proxies, want %d", step, s.ProxyCount(), len(boxes))
+		}
+		if step%50 != 0 {
+			continue
+		}
+
+		want := map[pairKey]bool{}
+		for a := range boxes {
+			for b := range boxes {
+				if a < b && boxes[a].Overlaps(boxes[b]) {
+					want[pairKey{a, b}] = true
+				}
+			}
+		}
+		if s.PairCount() != len(want) || len(replay) != len(want) {
+			t.Fatalf("step %d: got %d pairs and %d replayed, want %d", step, s.PairCount(), len(replay), len(want))
+		}
+		s.QueryPairs(func(idA, idB int) bool {
+			if !want[pairKey{idA, idB}] {
+				t.Fatalf("step %d: got pair %d %d, want none", step, idA, idB)
+			}
+			return true
+		})
+
+		query := randomBox()
+		found := map[int]bool{}
+		s.Query(query, func(id int) bool {
+			found[id] = true
+			return true
+		})
+		for id, aabb := range boxes {
+			if want := aabb.Overlaps(query); found[id] != want {
+				t.Fatalf("step %d: got found %v for proxy %d, want %v", step, found[id], id, want)
+			}
+		}
+	}
+}
+
+func TestSweepAndPruneEvents(t *testing.T) {
+	s := NewSweepAndPrune()
+	a := s.Insert(AABB{Point{0, 0}, Point{1, 1}}, nil)
+	b := s.Insert(AABB{Point{1, 1}, Point{2, 2}}, nil)
+	c := s.Insert(AABB{Point{5, 5}, Point{6, 6}}, nil)
+	s.Update(c, AABB{Point{1.5, 1.5}, Point{2.5, 2.5}})
+	s.Update(a, AABB{Point{-2, -2}, Point{-1, -1}})
+	s.Remove(b)
+	want := []PairEvent{
+		{a, b, true}, // touching boxes overlap
+		{b, c, true},
+		{a, b, false},
+		{b, c, false},
+	}
+	events := s.Events()
+	if len(events) != len(want) {
+		t.Fatalf("got events %v, want %v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("got event %v, want %v", events[i], want[i])
+		}
+	}
+	s.ClearEvents()
+	if len(s.Events()) != 0 || s.PairCount() != 0 {
+		t.Errorf("got %d events and %d pairs, want none", len(s.Events()), s.PairCount())
+	}
+}
+
+func TestSweepAndPruneInvalidProxy(t *testing.T) {
+	s := NewSweepAndPrune()
+	id := s.Insert(AABB{Point{0, 0}, Point{1, 1}}, nil)
+	s.Remove(id)
+	tests := []struct {
+		name string
+		fn   func(id int)
+	}{
+		{"Update", func(id int) { s.Update(id, AABB{}) }},
+		{"Remove", func(id int) { s.Remove(id) }},
+		{"UserData", func(id int) { s.UserData(id) }},
+		{"AABB", func(id int) { s.AABB(id) }},
+	}
+	for _, test := range tests {
+		for _, id := range []int{-1, id, 5} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%s: no panic for id %d", test.name, id)
+					}
+				}()
+				test.fn(id)
+			}()
+		}
+	}
+}
+
+func TestSweepAndPruneInvalidAABB(t *testing.T) {
+	s := NewSweepAndPrune()
+	id := s.Insert(AABB{Point{0, 0}, Point{1, 1}}, nil)
+	inf, nan := math.Inf(1), math.NaN()
+	tests := []struct {
+		name string
+		aabb AABB
+	}{
+		{"inverted", AABB{Point{1, 0}, Point{0, 1}}},
+		{"NaN", AABB{Point{0, 0}, Point{nan, 1}}},
+		{"infinite", AABB{Point{-inf, 0}, Point{1, 1}}},
+		{"unbounded", AABB{Point{-inf, -inf}, Point{inf, inf}}},
+	}
+	for _, test := range tests {
+		for _, fn := range []func(){
+			func() { s.Insert(test.aabb, nil) },
+			func() { s.Update(id, test.aabb) },
+		} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%s: no panic", test.name)
+					}
+				}()
+				fn()
+			}()
+		}
+	}
+
+	// The proxy is unchanged
+	if got, want := s.AABB(id), (AABB{Point{0, 0}, Point{1, 1}}); got != want {
+		t.Errorf("got AABB %v, want %v", got, want)
+	}
+	if got := s.ProxyCount(); got != 1 {
+		t.Errorf("got %d proxies, want 1", got)
+	}
+}
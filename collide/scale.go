@@ -0,0 +1,101 @@
+package collide
+
+import (
+	"math"
+)
+
+// scaledShape is a shape scaled uniformly about a center in local space.
+type scaledShape struct {
+	shape  Shape
+	center Point
+	scale  float64
+}
+
+// GetSupport returns the index of the furthest vertex in the given direction.
+func (s *scaledShape) GetSupport(dir Point) int {
+	return s.shape.GetSupport(dir)
+}
+
+// GetVertex returns the scaled vertex with the given index.
+func (s *scaledShape) GetVertex(index int) Point {
+	return s.center.Add(s.shape.GetVertex(index).Sub(s.center).Mul(s.scale))
+}
+
+// GetRadius returns the scaled radius of the shape.
+func (s *scaledShape) GetRadius() float64 {
+	return s.shape.GetRadius() * s.scale
+}
+
+// shapeExtent returns the maximum distance of the vertices of the shape from
+// its origin. It reports whether the extent is known for the type of shape.
+func shapeExtent(s Shape) (float64, bool) {
+	switch s := s.(type) {
+	case *Circle:
+		return s.Center.Length(), true
+	case *Polygon:
+		var extent float64
+		for _, p := range s.Points {
+			extent = math.Max(extent, p.Length())
+		}
+		return extent, true
+	case *Box:
+		return s.Center.Length() + s.Extents.Length(), true
+	}
+	return 0, false
+}
+
+// sweepBound returns an upper bound on the rate at which the distance between
+// the surface of the shape moving along the sweep and any fixed point can
+// decrease.
+func sweepBound(s Shape, sweep Sweep) (float64, bool) {
+	extent, ok := shapeExtent(s)
+	if !ok {
+		return 0, false
+	}
+
+	// The radius grows with the scale
+	growth := math.Abs(sweep.GetScale(1)-sweep.GetScale(0)) * s.GetRadius()
+	return sweep.Bound(extent) + growth, true
+}
+
+// scaled reports whether the size of either shape changes.
+func (input *TOIInput) scaled() bool {
+	return input.MotionA == nil && input.SweepA.scaled() ||
+		input.MotionB == nil && input.SweepB.scaled()
+}
+
+// getScales returns the scales of the shapes at time t.
+func (input *TOIInput) getScales(t float64) (float64, float64) {
+	scaleA, scaleB := 1.0, 1.0
+	if input.MotionA == nil {
+		scaleA = input.SweepA.GetScale(t)
+	}
+	if input.MotionB == nil {
+		scaleB = input.SweepB.GetScale(t)
+	}
+	return scaleA, scaleB
+}
+
+// getShapes returns the shapes at time t, which are scaled if their sweeps
+// scale them.
+func (input *TOIInput) getShapes(t float64) (Shape, Shape) {
+	a, b := input.A, input.B
+	if input.scaled() {
+		scaleA, scaleB := input.getScales(t)
+		a = &scaledShape{shape: a, center: input.SweepA.LocalCenter, scale: scaleA}
+		b = &scaledShape{shape: b, center: input.SweepB.LocalCenter, scale: scaleB}
+	}
+	return a, b
+}
+
+// scaledBound returns an upper bound on the rate at which the distance between
+// the surfaces of the shapes can decrease. It reports whether the bound is
+// known, which requires both shapes to move along sweeps.
+func (input *TOIInput) scaledBound() (float64, bool) {
+	if input.MotionA != nil || input.MotionB != nil {
+		return 0, false
+	}
+	boundA, okA := sweepBound(input.A, input.SweepA)
+	boundB, okB := sweepBound(input.B, input.SweepB)
+	return boundA + boundB, okA && okB
+}
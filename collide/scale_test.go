@@ -0,0 +1,106 @@
+package collide
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScaledShape(t *testing.T) {
+	square := Rect(1, 1, 2, 2)
+	s := &scaledShape{shape: square, center: Point{1, 1}, scale: 2}
+	for i, p := range square.Points {
+		want := Point{1, 1}.Add(p.Sub(Point{1, 1}).Mul(2))
+		if got := s.GetVertex(i); !approxEqualPoint(got, want) {
+			t.Errorf("got vertex %v, want %v", got, want)
+		}
+	}
+	circle := &scaledShape{shape: &Circle{Radius: 1.5}, scale: 3}
+	if got := circle.GetRadius(); !approxEqual(got, 4.5) {
+		t.Errorf("got radius %v, want 4.5", got)
+	}
+	if got := (Sweep{S0: 2, S1: 3}).GetScale(0.5); got != 1 {
+		t.Errorf("got scale %v for an unscaled sweep, want 1", got)
+	}
+}
+
+func TestTimeOfImpactScale(t *testing.T) {
+	settings := MeterSettings()
+	small := &Circle{Radius: 0.5}
+	tests := []struct {
+		name  string
+		input TOIInput
+		state TOIState
+		time  float64
+	}{
+		{
+			name: "expanding circle",
+			input: TOIInput{
+				A:      &Circle{Radius: 1},
+				B:      Rectangle(Point{4, 0}, Point{0.5, 0.5}),
+				SweepA: Sweep{S0: 1, S1: 5, Scaled: true},
+			},
+			state: TOIHit,
+			time:  (2.5 + 0.015) / 4,
+		},
+		{
+			name: "growing polygon",
+			input: TOIInput{
+				A:      Rectangle(Point{}, Point{1, 1}),
+				B:      small,
+				SweepA: Sweep{S0: 1, S1: 3, Scaled: true},
+				SweepB: Sweep{P0: Point{3, 0}, P1: Point{3, 0}},
+			},
+			state: TOIHit,
+			time:  (2 - 0.485) / 2,
+		},
+		{
+			name: "growing polygon and moving circle",
+			input: TOIInput{
+				A:      Rectangle(Point{}, Point{1, 1}),
+				B:      small,
+				SweepA: Sweep{S0: 1, S1: 2, Scaled: true},
+				SweepB: Sweep{P0: Point{10, 0}, P1: Point{0, 0}},
+			},
+			state: TOIHit,
+			time:  (9 - 0.485) / 11,
+		},
+		{
+			name: "shrinking polygon",
+			input: TOIInput{
+				A:      Rectangle(Point{}, Point{2, 2}),
+				B:      small,
+				SweepA: Sweep{S0: 1, S1: 0.5, Scaled: true},
+				SweepB: Sweep{P0: Point{5, 0}, P1: Point{3, 0}},
+			},
+			state: TOISeparated,
+			time:  1,
+		},
+		{
+			name: "unknown extent",
+			input: TOIInput{
+				A:      &capsule{Point{-1, 0}, Point{1, 0}, 0.5},
+				B:      small,
+				SweepA: Sweep{S0: 1, S1: 2, Scaled: true},
+			},
+			state: TOIFailed,
+		},
+	}
+	for _, test := range tests {
+		output := TimeOfImpact(&test.input)
+		if output.State != test.state {
+			t.Errorf("%s: got state %v, want %v", test.name, output.State, test.state)
+			continue
+		}
+		if math.Abs(output.Time-test.time) > 0.001 {
+			t.Errorf("%s: got time %v, want %v", test.name, output.Time, test.time)
+		}
+		if output.State != TOIHit {
+			continue
+		}
+		a, b := test.input.getShapes(output.Time)
+		target := math.Max(settings.LinearSlop, a.GetRadius()+b.GetRadius()+settings.TOITarget)
+		if distance := coreDistance(&test.input, output.Time); math.Abs(distance-target) > settings.TOITolerance {
+			t.Errorf("%s: got distance %v at the time of impact, want %v", test.name, distance, target)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package collide
+
+// Settings holds the tolerances and iteration limits used by TimeOfImpact,
+// ShapeCast, the collision routines and the broadphase. Lengths depend on
+// the unit system of the shapes, so the settings must match the scale of
+// the world.
+//
+// Settings are used as given, and zero is a valid value for some fields,
+// so start from MeterSettings or PixelSettings when changing them.
+// A nil *Settings stands for MeterSettings.
+type Settings struct {
+	// LinearSlop is a small length used as a collision tolerance.
+	LinearSlop float64
+	// SpeculativeDistance is the margin within which Settings.Collide
+	// returns speculative contacts.
+	SpeculativeDistance float64
+
+	// TOITarget is the separation of the surfaces that TimeOfImpact aims
+	// for. It is usually negative so that the shapes end up touching.
+	// The distance between the cores of the shapes is kept at least
+	// LinearSlop, so polygons stop LinearSlop apart.
+	TOITarget float64
+	// TOITolerance is the tolerance of the separation reached by TimeOfImpact.
+	TOITolerance float64
+	// TOIMaxIterations limits the number of separating axes computed by
+	// TimeOfImpact.
+	TOIMaxIterations int
+	// TOIMaxPushBackIterations limits the number of deepest points resolved
+	// on each separating axis.
+	TOIMaxPushBackIterations int
+	// TOIMaxRootIterations limits the number of iterations of the root finder.
+	TOIMaxRootIterations int
+
+	// CastMaxIterations limits the number of iterations of ShapeCast.
+	CastMaxIterations int
+
+	// AABBMargin is the distance by which DynamicTree enlarges the bounding
+	// boxes of proxies, so that small moves do not change the tree.
+	AABBMargin float64
+	// AABBMultiplier scales the displacement of moving proxies to predict
+	// their future bounding boxes.
+	AABBMultiplier float64
+}
+
+// MeterSettings returns the default settings, for worlds measured in meters.
+func MeterSettings() Settings {
+	return Settings{
+		LinearSlop:               0.005,
+		SpeculativeDistance:      0.02,
+		TOITarget:                -0.015,
+		TOITolerance:             0.00125,
+		TOIMaxIterations:         100,
+		TOIMaxPushBackIterations: 20,
+		TOIMaxRootIterations:     50,
+		CastMaxIterations:        20,
+		AABBMargin:               0.1,
+		AABBMultiplier:           4,
+	}
+}
+
+// PixelSettings returns settings for worlds measured in pixels.
+// They scale the lengths of MeterSettings by roughly 100 pixels per meter.
+func PixelSettings() Settings {
+	return Settings{
+		LinearSlop:               0.5,
+		SpeculativeDistance:      2,
+		TOITarget:                -1.5,
+		TOITolerance:             0.125,
+		TOIMaxIterations:         100,
+		TOIMaxPushBackIterations: 20,
+		TOIMaxRootIterations:     50,
+		CastMaxIterations:        20,
+		AABBMargin:               10,
+		AABBMultiplier:           4,
+	}
+}
+
+// settingsOrDefault returns a copy of s, or MeterSettings if s is nil.
+func settingsOrDefault(s *Settings) Settings {
+	if s == nil {
+		return MeterSettings()
+	}
+	return *s
+}
+
+// Collide is like CollideSpeculative, using SpeculativeDistance as the margin.
+// A nil receiver uses MeterSettings.
+func (s *Settings) Collide(a Shape, xfa Transform, b Shape, xfb Transform) *Collision {
+	return CollideSpeculative(a, xfa, b, xfb, settingsOrDefault(s).SpeculativeDistance)
+}
+
+// CollideTo is like CollideSpeculativeTo, using SpeculativeDistance as the
+// margin.
+func (s *Settings) CollideTo(c *Collision, a Shape, xfa Transform, b Shape, xfb Transform) bool {
+	return CollideSpeculativeTo(c, a, xfa, b, xfb, settingsOrDefault(s).SpeculativeDistance)
+}
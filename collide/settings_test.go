@@ -0,0 +1,76 @@
+package collide
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSettingsOrDefault(t *testing.T) {
+	if got, want := settingsOrDefault(nil), MeterSettings(); got != want {
+		t.Errorf("got %+v for nil settings, want %+v", got, want)
+	}
+
+	// Zero fields are kept as they are
+	want := PixelSettings()
+	want.SpeculativeDistance = 0
+	want.TOITarget = 0
+	if got := settingsOrDefault(&want); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	pixels := PixelSettings()
+	if got := settingsOrDefault(&pixels); got != pixels {
+		t.Errorf("got %+v, want %+v", got, pixels)
+	}
+}
+
+func TestSettingsPresets(t *testing.T) {
+	// The presets return independent copies
+	s := MeterSettings()
+	s.LinearSlop = 1
+	if MeterSettings().LinearSlop == 1 {
+		t.Error("MeterSettings changed after modifying a copy")
+	}
+	s = PixelSettings()
+	s.AABBMargin = 0
+	if PixelSettings().AABBMargin == 0 {
+		t.Error("PixelSettings changed after modifying a copy")
+	}
+}
+
+func TestSettingsCollide(t *testing.T) {
+	// Nil settings use the default speculative distance
+	circle := &Circle{Radius: 1}
+	origin := NewTransform(Point{}, 0)
+	near := NewTransform(Point{2.01, 0}, 0)
+	if c := (*Settings)(nil).Collide(circle, origin, circle, near); c == nil {
+		t.Error("got no collision within the default speculative distance")
+	}
+	if c := (&Settings{}).Collide(circle, origin, circle, near); c != nil {
+		t.Errorf("got collision %v without a speculative distance", c)
+	}
+	pixels := PixelSettings()
+	if c := pixels.Collide(circle, origin, circle, NewTransform(Point{3.5, 0}, 0)); c == nil {
+		t.Error("got no collision within the pixel speculative distance")
+	}
+}
+
+func TestRayCastSettings(t *testing.T) {
+	// The generic ray cast uses the settings of the input
+	square := hull{Rectangle(Point{}, Point{10, 10})}
+	origin := NewTransform(Point{}, 0)
+	pixels := PixelSettings()
+	input := RayCastInput{Origin: Point{-50, 0}, Direction: Point{1, 0}, MaxFraction: 100}
+	meter := RayCast(square, origin, &input)
+	input.Settings = &pixels
+	pixel := RayCast(square, origin, &input)
+	if !meter.Hit || !pixel.Hit {
+		t.Fatalf("got hits %v and %v, want both", meter.Hit, pixel.Hit)
+	}
+	if d := math.Abs(meter.Fraction - 40); d > 2*MeterSettings().LinearSlop {
+		t.Errorf("got fraction %v with the default settings, want about 40", meter.Fraction)
+	}
+	if d := math.Abs(pixel.Fraction - meter.Fraction); d <= 2*MeterSettings().LinearSlop || d > 2*pixels.LinearSlop {
+		t.Errorf("got fraction %v with pixel settings, want within %v of 40", pixel.Fraction, 2*pixels.LinearSlop)
+	}
+}
@@ -1,9 +1,18 @@
 package collide
 
-// Shape represents a shape.
+// Shape represents a convex shape. A shape is described by its vertices
+// in local space, which are rounded by its radius.
+//
+// Shapes other than the ones provided by this package can be used with
+// Collide by implementing this interface and registering colliders for them
+// with RegisterCollider.
 type Shape interface {
-	getSupport(dir Point) int
-	getVertex(index int) Point
+	// GetSupport returns the index of the furthest vertex in the given direction.
+	GetSupport(dir Point) int
+	// GetVertex returns the vertex with the given index.
+	GetVertex(index int) Point
+	// GetRadius returns the radius of the shape.
+	GetRadius() float64
 }
 
 // Circle represents a circle shape.
@@ -12,14 +21,21 @@ type Circle struct {
 	Radius float64
 }
 
-func (c *Circle) getSupport(dir Point) int {
+// GetSupport returns the index of the center of the circle.
+func (c *Circle) GetSupport(dir Point) int {
 	return 0
 }
 
-func (c *Circle) getVertex(index int) Point {
+// GetVertex returns the center of the circle.
+func (c *Circle) GetVertex(index int) Point {
 	return c.Center
 }
 
+// GetRadius returns the radius of the circle.
+func (c *Circle) GetRadius() float64 {
+	return c.Radius
+}
+
 // Polygon represents a collection of points.
 type Polygon struct {
 	Points  []Point
@@ -46,8 +62,8 @@ func NewPolygon(points ...Point) *Polygon {
 	}
 }
 
-// getSupport returns the furthest vertex of the polygon in the given direction.
-func (p *Polygon) getSupport(dir Point) int {
+// GetSupport returns the furthest vertex of the polygon in the given direction.
+func (p *Polygon) GetSupport(dir Point) int {
 	index := 0
 	maxDist := Dot(dir, p.Points[index])
 	for i := 1; i < len(p.Points); i++ {
@@ -60,10 +76,16 @@ func (p *Polygon) getSupport(dir Point) int {
 	return index
 }
 
-func (p *Polygon) getVertex(index int) Point {
+// GetVertex returns the vertex of the polygon with the given index.
+func (p *Polygon) GetVertex(index int) Point {
 	return p.Points[index]
 }
 
+// GetRadius returns zero since polygons are not rounded.
+func (p *Polygon) GetRadius() float64 {
+	return 0
+}
+
 // Rectangle returns a rectangular polygon shape with the given center and half extents.
 func Rectangle(center, extents Point) *Polygon {
 	return NewPolygon(
@@ -78,3 +100,62 @@ func Rectangle(center, extents Point) *Polygon {
 func Rect(x, y, w, h float64) *Polygon {
 	return Rectangle(Point{x, y}, Point{w / 2, h / 2})
 }
+
+// Box represents a rectangle aligned with the axes of its local space.
+// Collisions involving boxes avoid rotating normals and vertices when the
+// box's transform has no rotation.
+type Box struct {
+	Center  Point
+	Extents Point // half extents
+}
+
+// boxNormals are the edge normals of a box.
+var boxNormals = [4]Point{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+
+// GetSupport returns the furthest vertex of the box in the given direction.
+func (b *Box) GetSupport(dir Point) int {
+	if dir.Y < 0 {
+		if dir.X < 0 {
+			return 0
+		}
+		return 1
+	}
+	if dir.X < 0 {
+		return 3
+	}
+	return 2
+}
+
+// GetVertex returns the vertex of the box with the given index.
+// The vertices are in the same order as those of Rectangle.
+func (b *Box) GetVertex(index int) Point {
+	switch index {
+	case 0:
+		return b.Center.Add(Point{-b.Extents.X, -b.Extents.Y})
+	case 1:
+		return b.Center.Add(Point{b.Extents.X, -b.Extents.Y})
+	case 2:
+		return b.Center.Add(Point{b.Extents.X, b.Extents.Y})
+	default:
+		return b.Center.Add(Point{-b.Extents.X, b.Extents.Y})
+	}
+}
+
+// GetRadius returns zero since boxes are not rounded.
+func (b *Box) GetRadius() float64 {
+	return 0
+}
+
+// Polygon returns the box as a polygon.
+func (b *Box) Polygon() *Polygon {
+	return Rectangle(b.Center, b.Extents)
+}
+
+// polygon returns the box as a polygon whose points are stored in points,
+// so that the conversion does not allocate.
+func (b *Box) polygon(points *[4]Point) Polygon {
+	for i := range points {
+		points[i] = b.GetVertex(i)
+	}
+	return Polygon{Points: points[:], Normals: boxNormals[:]}
+}
@@ -0,0 +1,90 @@
+package collide
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// sameCollision reports whether two collisions have the same normal, depth
+// and number of points.
+func sameCollision(c1, c2 *Collision) bool {
+	if c1 == nil || c2 == nil {
+		return c1 == c2
+	}
+	return approxEqualPoint(c1.Normal, c2.Normal) && approxEqual(c1.Depth, c2.Depth) && c1.Count == c2.Count
+}
+
+func TestBox(t *testing.T) {
+	// The fast paths for boxes agree with the paths for polygons
+	r := rand.New(rand.NewSource(1))
+	randomPoint := func(size float64) Point {
+		return Point{(r.Float64() - 0.5) * size, (r.Float64() - 0.5) * size}
+	}
+	randomBox := func() *Box {
+		return &Box{randomPoint(2), Point{0.1 + r.Float64(), 0.1 + r.Float64()}}
+	}
+	polygon := NewPolygon(Point{0, 0}, Point{1, 0.2}, Point{0.6, 1.1}, Point{-0.4, 0.7})
+	for i := 0; i < 20000; i++ {
+		a, b := randomBox(), randomBox()
+		circle := &Circle{randomPoint(2), 0.1 + r.Float64()}
+		polyA, polyB := a.Polygon(), b.Polygon()
+		xfa := NewTransform(randomPoint(4), 0)
+		xfb := NewTransform(randomPoint(4), 0)
+		if r.Intn(4) == 0 {
+			xfa = NewTransform(xfa.Position, r.Float64()*6)
+		}
+		rotated := NewTransform(randomPoint(4), r.Float64()*6)
+		margin := r.Float64() * 0.3
+
+		pairs := []struct {
+			name   string
+			a, b   Shape
+			pa, pb Shape
+			xfb    Transform
+		}{
+			{"boxes", a, b, polyA, polyB, xfb},
+			{"box and circle", a, circle, polyA, circle, xfb},
+			{"box and polygon", a, polygon, polyA, polygon, rotated},
+		}
+		for _, p := range pairs {
+			c1 := CollideSpeculative(p.a, xfa, p.b, p.xfb, margin)
+			c2 := CollideSpeculative(p.pa, xfa, p.pb, p.xfb, margin)
+			if !sameCollision(c1, c2) {
+				t.Fatalf("%s: got collision %v, want %v", p.name, c1, c2)
+			}
+			if Overlap(p.a, xfa, p.b, p.xfb) != Overlap(p.pa, xfa, p.pb, p.xfb) {
+				t.Fatalf("%s: got overlap %v, want %v", p.name, !Overlap(p.pa, xfa, p.pb, p.xfb), Overlap(p.pa, xfa, p.pb, p.xfb))
+			}
+		}
+
+		if got, want := ComputeAABB(a, xfa), ComputeAABB(polyA, xfa); !approxEqualPoint(got.Min, want.Min) || !approxEqualPoint(got.Max, want.Max) {
+			t.Fatalf("got bounding box %v, want %v", got, want)
+		}
+
+		point := randomPoint(6)
+		if a.TestPoint(xfa, point) != polyA.TestPoint(xfa, point) {
+			t.Fatalf("got inside %v for %v, want %v", !polyA.TestPoint(xfa, point), point, polyA.TestPoint(xfa, point))
+		}
+		d1, n1, f1 := SignedDistance(a, xfa, point)
+		d2, n2, f2 := SignedDistance(polyA, xfa, point)
+		if !approxEqual(d1, d2) || !approxEqualPoint(n1, n2) || f1 != f2 {
+			t.Fatalf("got signed distance %v %v %v, want %v %v %v", d1, n1, f1, d2, n2, f2)
+		}
+
+		input := RayCastInput{Origin: randomPoint(8), Direction: randomPoint(8), MaxFraction: 1}
+		o1, o2 := RayCast(a, xfa, &input), RayCast(polyA, xfa, &input)
+		if o1.Hit != o2.Hit || !approxEqual(o1.Fraction, o2.Fraction) || !approxEqualPoint(o1.Normal, o2.Normal) {
+			t.Fatalf("got ray cast %v, want %v", o1, o2)
+		}
+	}
+}
+
+func TestBoxArea(t *testing.T) {
+	box := &Box{Center: Point{1, 2}, Extents: Point{1.5, 0.5}}
+	if area := box.Area(); !approxEqual(area, 3) {
+		t.Errorf("got area %v, want 3", area)
+	}
+	if area := box.Polygon().Area(); !approxEqual(area, 3) {
+		t.Errorf("got polygon area %v, want 3", area)
+	}
+}
@@ -21,25 +21,20 @@ type separation struct {
 	local  Point // local point
 }
 
-func newSeparation(shapeA, shapeB Shape) *separation {
-	return &separation{
-		shapeA: shapeA,
-		shapeB: shapeB,
-	}
-}
-
 // init initializes the separation function.
-func (s *separation) init(cache *SimplexCache, xfa, xfb Transform) {
+func (s *separation) init(shapeA, shapeB Shape, cache *SimplexCache, xfa, xfb Transform) {
+	s.shapeA = shapeA
+	s.shapeB = shapeB
 	if cache.count == 1 {
 		// One point on A and one on B.
 		s.kind = axisPoints
 
 		indexA := cache.indexA[0]
-		pointA := s.shapeA.getVertex(indexA)
+		pointA := s.shapeA.GetVertex(indexA)
 		pointA = xfa.Mul(pointA)
 
 		indexB := cache.indexB[0]
-		pointB := s.shapeB.getVertex(indexB)
+		pointB := s.shapeB.GetVertex(indexB)
 		pointB = xfb.Mul(pointB)
 
 		s.axis = pointB.Sub(pointA).Normalize()
@@ -49,14 +44,14 @@ func (s *separation) init(cache *SimplexCache, xfa, xfb Transform) {
 
 		indexB1 := cache.indexB[0]
 		indexB2 := cache.indexB[1]
-		pointB1 := s.shapeB.getVertex(indexB1)
-		pointB2 := s.shapeB.getVertex(indexB2)
+		pointB1 := s.shapeB.GetVertex(indexB1)
+		pointB2 := s.shapeB.GetVertex(indexB2)
 		s.axis = CrossPS(pointB2.Sub(pointB1).Normalize(), 1.0)
 		s.local = pointB1.Add(pointB2).Mul(0.5)
 
 		pointB := xfb.Mul(s.local)
 		indexA := cache.indexA[0]
-		pointA := s.shapeA.getVertex(indexA)
+		pointA := s.shapeA.GetVertex(indexA)
 		pointA = xfa.Mul(pointA)
 
 		normal := xfb.Rotation.Mul(s.axis)
@@ -70,14 +65,14 @@ func (s *separation) init(cache *SimplexCache, xfa, xfb Transform) {
 
 		indexA1 := cache.indexA[0]
 		indexA2 := cache.indexA[1]
-		pointA1 := s.shapeA.getVertex(indexA1)
-		pointA2 := s.shapeA.getVertex(indexA2)
+		pointA1 := s.shapeA.GetVertex(indexA1)
+		pointA2 := s.shapeA.GetVertex(indexA2)
 		s.axis = CrossPS(pointA2.Sub(pointA1).Normalize(), 1.0)
 		s.local = pointA1.Add(pointA2).Mul(0.5)
 
 		pointA := xfa.Mul(s.local)
 		indexB := cache.indexB[0]
-		pointB := s.shapeB.getVertex(indexB)
+		pointB := s.shapeB.GetVertex(indexB)
 		pointB = xfb.Mul(pointB)
 
 		normal := xfa.Rotation.Mul(s.axis)
@@ -95,12 +90,12 @@ func (s *separation) MinSeparation(xfa, xfb Transform) (int, int, float64) {
 		axisA := xfa.Rotation.MulT(s.axis)
 		axisB := xfb.Rotation.MulT(s.axis.Neg())
 
-		indexA := s.shapeA.getSupport(axisA)
-		pointA := s.shapeA.getVertex(indexA)
+		indexA := s.shapeA.GetSupport(axisA)
+		pointA := s.shapeA.GetVertex(indexA)
 		pointA = xfa.Mul(pointA)
 
-		indexB := s.shapeB.getSupport(axisB)
-		pointB := s.shapeB.getVertex(indexB)
+		indexB := s.shapeB.GetSupport(axisB)
+		pointB := s.shapeB.GetVertex(indexB)
 		pointB = xfb.Mul(pointB)
 
 		separation := Dot(pointB.Sub(pointA), s.axis)
@@ -110,8 +105,8 @@ func (s *separation) MinSeparation(xfa, xfb Transform) (int, int, float64) {
 		normal := xfa.Rotation.Mul(s.axis)
 		axisB := xfb.Rotation.MulT(normal.Neg())
 
-		indexB := s.shapeB.getSupport(axisB)
-		pointB := s.shapeB.getVertex(indexB)
+		indexB := s.shapeB.GetSupport(axisB)
+		pointB := s.shapeB.GetVertex(indexB)
 		pointB = xfb.Mul(pointB)
 		pointA := xfa.Mul(s.local)
 
@@ -122,8 +117,8 @@ func (s *separation) MinSeparation(xfa, xfb Transform) (int, int, float64) {
 		normal := xfb.Rotation.Mul(s.axis)
 		axisA := xfa.Rotation.MulT(normal.Neg())
 
-		indexA := s.shapeA.getSupport(axisA)
-		pointA := s.shapeA.getVertex(indexA)
+		indexA := s.shapeA.GetSupport(axisA)
+		pointA := s.shapeA.GetVertex(indexA)
 		pointA = xfa.Mul(pointA)
 		pointB := xfb.Mul(s.local)
 
@@ -139,21 +134,21 @@ func (s *separation) MinSeparation(xfa, xfb Transform) (int, int, float64) {
 func (s *separation) Evaluate(indexA int, xfa Transform, indexB int, xfb Transform) float64 {
 	switch s.kind {
 	case axisPoints:
-		a := xfa.Mul(s.shapeA.getVertex(indexA))
-		b := xfb.Mul(s.shapeB.getVertex(indexB))
+		a := xfa.Mul(s.shapeA.GetVertex(indexA))
+		b := xfb.Mul(s.shapeB.GetVertex(indexB))
 		separation := Dot(b.Sub(a), s.axis)
 		return separation
 
 	case axisFaceA:
 		normal := xfa.Rotation.Mul(s.axis)
 		a := xfa.Mul(s.local)
-		b := xfb.Mul(s.shapeB.getVertex(indexB))
+		b := xfb.Mul(s.shapeB.GetVertex(indexB))
 		separation := Dot(b.Sub(a), normal)
 		return separation
 
 	case axisFaceB:
 		normal := xfb.Rotation.Mul(s.axis)
-		a := xfa.Mul(s.shapeA.getVertex(indexA))
+		a := xfa.Mul(s.shapeA.GetVertex(indexA))
 		b := xfb.Mul(s.local)
 		separation := Dot(a.Sub(b), normal)
 		return separation
@@ -163,54 +158,145 @@ func (s *separation) Evaluate(indexA int, xfa Transform, indexB int, xfb Transfo
 	}
 }
 
-// CCD via the local separating axis method. This seeks progression
+// TOIInput is the input to TimeOfImpact.
+//
+// The shapes move along MotionA and MotionB, or along SweepA and SweepB
+// if the motions are nil. If MotionBound is positive, the time of impact is
+// found by conservative advancement, which never misses a hit for motions
+// within the bound. A loose bound takes small steps, and if the maximum
+// number of iterations is reached, the state is TOIFailed and the time is
+// the last one at which the shapes are known to be apart.
+// Otherwise the local separating axis method is used,
+// which assumes that the motions are close to linear.
+//
+// If a sweep scales its shape, conservative advancement is used with a
+// bound computed from the sweeps, unless MotionBound is given. The bound
+// is only known for circles, polygons and boxes moving along sweeps.
+type TOIInput struct {
+	A           Shape
+	B           Shape
+	SweepA      Sweep
+	SweepB      Sweep
+	MotionA     Motion    // motion of A, or nil to use SweepA
+	MotionB     Motion    // motion of B, or nil to use SweepB
+	MotionBound float64   // bound on the relative motion of any points of A and B per unit time
+	Settings    *Settings // settings, or nil for MeterSettings
+}
+
+// getTransforms returns the transforms of the shapes at time t.
+func (input *TOIInput) getTransforms(t float64) (Transform, Transform) {
+	var xfa, xfb Transform
+	if input.MotionA != nil {
+		xfa = input.MotionA.GetTransform(t)
+	} else {
+		xfa = input.SweepA.GetTransform(t)
+	}
+	if input.MotionB != nil {
+		xfb = input.MotionB.GetTransform(t)
+	} else {
+		xfb = input.SweepB.GetTransform(t)
+	}
+	return xfa, xfb
+}
+
+// TOIState represents the result of TimeOfImpact.
+type TOIState int
+
+const (
+	TOIUnknown    TOIState = iota // the time of impact was not computed
+	TOIFailed                     // the solver did not converge
+	TOIOverlapped                 // the shapes are initially overlapped
+	TOIHit                        // the shapes come into contact
+	TOISeparated                  // the shapes remain separated
+)
+
+// TOIOutput is the output of TimeOfImpact.
+type TOIOutput struct {
+	State          TOIState
+	Time           float64 // time of impact in [0, 1]
+	Normal         Point   // contact normal from A to B, if State is TOIHit
+	Iterations     int     // number of separating axes computed
+	RootIterations int     // total number of root finder iterations
+}
+
+// TimeOfImpact computes the time at which two moving shapes come into
+// contact. It uses the local separating axis method, which seeks progression
 // by computing the largest time at which separation is maintained.
-func TimeOfImpact(simplex *Simplex, a Shape, sweepA Sweep, b Shape, sweepB Sweep) float64 {
-	const target = 0.01
-	const tolerance = 0.25 * 0.005
+//
+// The time is 0 if the shapes are initially overlapped, 1 if they remain
+// separated, and the time reached by the solver if it fails to converge.
+func TimeOfImpact(input *TOIInput) TOIOutput {
+	a, b := input.A, input.B
+	settings := settingsOrDefault(input.Settings)
+
+	// The distance is computed between the cores of rounded shapes,
+	// so aim for the target separation of their surfaces.
+	totalRadius := a.GetRadius() + b.GetRadius()
+	target := math.Max(settings.LinearSlop, totalRadius+settings.TOITarget)
+	tolerance := settings.TOITolerance
+
+	if input.MotionBound > 0 {
+		return conservativeAdvancement(input, &settings, input.MotionBound)
+	}
+	if input.scaled() {
+		bound, ok := input.scaledBound()
+		if !ok {
+			return TOIOutput{State: TOIFailed}
+		}
+		return conservativeAdvancement(input, &settings, bound)
+	}
+	if output, ok := analyticTimeOfImpact(input, target, tolerance); ok {
+		return output
+	}
 
-	cache := &SimplexCache{}
+	var output TOIOutput
+	var simplex Simplex
+	var cache SimplexCache
+	var fcn separation
 	t1 := 0.0
 
 	// The outer loop progressively attempts to compute new separating axes.
 	// This loop terminates when an axis is repeated (no progress is made).
-loop:
-	for i := 0; i < 100; i++ {
+	for output.State == TOIUnknown {
 		// Get the closest features at t1.
-		xfa, xfb := sweepA.GetTransform(t1), sweepB.GetTransform(t1)
-		simplex.ReadCache(cache, a, xfa, b, xfb)
+		xfa, xfb := input.getTransforms(t1)
+		simplex.ReadCache(&cache, a, xfa, b, xfb)
 		simplex.GJK(a, xfa, b, xfb)
-		simplex.WriteCache(cache)
+		simplex.WriteCache(&cache)
 		distance := simplex.ClosestPoint().Length()
 
 		// If the shapes are overlapped, we give up on continuous collision.
 		if distance <= 0 {
-			// Failure!
-			return 0
+			output.State = TOIOverlapped
+			output.Time = 0
+			break
 		}
 
 		if distance < target+tolerance {
 			// Victory!
+			output.State = TOIHit
+			output.Time = t1
+			output.Normal = simplex.ClosestPoint().Div(distance)
 			break
 		}
 
 		// Initialize the separating axis.
-		fcn := newSeparation(a, b)
-		fcn.init(cache, xfa, xfb)
+		fcn.init(a, b, &cache, xfa, xfb)
 
 		// Compute the TOI on the separating axis. We do this by successively
 		// resolving the deepest point. This loop is bounded by the number of vertices.
 		t2 := 1.0
-		for i := 0; i < 20; i++ {
+		for i := 0; i < settings.TOIMaxPushBackIterations; i++ {
 			// Find the deepest point at t2. Store the witness points.
-			var s2 float64
-			xfa, xfb := sweepA.GetTransform(t2), sweepB.GetTransform(t2)
+			xfa, xfb := input.getTransforms(t2)
 			indexA, indexB, s2 := fcn.MinSeparation(xfa, xfb)
 
 			// Is the final configuration separated?
 			if s2 > target+tolerance {
 				// Victory!
-				return 1.0
+				output.State = TOISeparated
+				output.Time = 1
+				break
 			}
 
 			// Has the separation reached tolerance?
@@ -221,25 +307,31 @@ loop:
 			}
 
 			// Compute the initial separation of the witness points.
-			xfa, xfb = sweepA.GetTransform(t1), sweepB.GetTransform(t1)
+			xfa, xfb = input.getTransforms(t1)
 			s1 := fcn.Evaluate(indexA, xfa, indexB, xfb)
 
 			// Check for initial overlap. This might happen if the root finder
 			// runs out of iterations.
 			if s1 < target-tolerance {
-				// Failure
-				break loop
+				output.State = TOIFailed
+				output.Time = t1
+				break
 			}
 
 			// Check for touching
 			if s1 <= target+tolerance {
 				// Victory! t1 should hold the TOI (could be 0).
-				break loop
+				output.State = TOIHit
+				output.Time = t1
+				output.Normal = simplex.ClosestPoint().Normalize()
+				break
 			}
 
 			// Compute 1D root of: f(x) - target = 0
 			a1, a2 := t1, t2
-			for j := 0; j < 50; j++ {
+			for j := 0; j < settings.TOIMaxRootIterations; j++ {
+				output.RootIterations++
+
 				var t float64
 				if (j & 1) != 0 {
 					// Secant rule to improve convergence.
@@ -249,7 +341,7 @@ loop:
 					t = 0.5 * (a1 + a2)
 				}
 
-				xfa, xfb := sweepA.GetTransform(t), sweepB.GetTransform(t)
+				xfa, xfb := input.getTransforms(t)
 				s := fcn.Evaluate(indexA, xfa, indexB, xfb)
 				if math.Abs(s-target) < tolerance {
 					// t2 holds a tentative value for t1
@@ -267,6 +359,344 @@ loop:
 				}
 			}
 		}
+
+		output.Iterations++
+		if output.State == TOIUnknown && output.Iterations >= settings.TOIMaxIterations {
+			// Root finder got stuck. Semi-victory.
+			output.State = TOIFailed
+			output.Time = t1
+		}
+	}
+	return output
+}
+
+// TimeOfImpactManifold is like TimeOfImpact, but also stores in c the
+// collision of the shapes at the time of impact, computed from the final
+// separating axis. Unlike a collision calculated by Collide, it includes
+// contacts even though the shapes are separated by the target gap.
+//
+// If the shapes are initially overlapped, c holds their collision at time
+// zero. If they do not come into contact, c is reset to the zero Collision.
+func TimeOfImpactManifold(input *TOIInput, c *Collision) TOIOutput {
+	output := TimeOfImpact(input)
+	a, b := input.getShapes(output.Time)
+	xfa, xfb := input.getTransforms(output.Time)
+
+	switch output.State {
+	case TOIHit:
+	case TOIOverlapped:
+		CollideTo(c, a, xfa, b, xfb)
+		return output
+	default:
+		*c = Collision{}
+		return output
+	}
+
+	// Recover the separating axis at the time of impact
+	var simplex Simplex
+	var cache SimplexCache
+	var fcn separation
+	simplex.GJK(a, xfa, b, xfb)
+	simplex.WriteCache(&cache)
+	fcn.init(a, b, &cache, xfa, xfb)
+
+	// Keep the contacts within the speculative distance of the target gap
+	settings := settingsOrDefault(input.Settings)
+	totalRadius := a.GetRadius() + b.GetRadius()
+	margin := math.Max(settings.LinearSlop, totalRadius+settings.TOITarget) - totalRadius +
+		settings.SpeculativeDistance
+	if fcn.kind != axisPoints && clipFaces(c, &fcn, &cache, xfa, xfb, margin) {
+		return output
+	}
+
+	// Otherwise the contact is midway between the closest points
+	pointA, pointB := simplex.WitnessPoints()
+	pointA, pointB = xfa.Mul(pointA), xfb.Mul(pointB)
+	distance := pointB.Sub(pointA).Length()
+	normal := output.Normal
+	if distance > 0 {
+		normal = pointB.Sub(pointA).Div(distance)
+	}
+	radiusA, radiusB := a.GetRadius(), b.GetRadius()
+	pointA = pointA.Add(normal.Mul(radiusA))
+	pointB = pointB.Sub(normal.Mul(radiusB))
+
+	*c = Collision{Normal: normal}
+	c.addPoint(pointA.Add(pointB).Mul(0.5), distance-radiusA-radiusB)
+	return output
+}
+
+// clipFaces calculates the collision of two polygons by clipping against the
+// reference face of the separating axis. It reports whether both shapes are
+// polygons and a collision was found.
+func clipFaces(c *Collision, fcn *separation, cache *SimplexCache, xfa, xfb Transform, margin float64) bool {
+	var pointsA, pointsB [4]Point
+	polyA, okA := asPolygon(fcn.shapeA, &pointsA)
+	polyB, okB := asPolygon(fcn.shapeB, &pointsB)
+	if !okA || !okB {
+		return false
+	}
+
+	if fcn.kind == axisFaceA {
+		edge := featureBetween(cache.indexA[0], cache.indexA[1]).Index
+		return clipPolygons(c, &polyA, xfa, &polyB, xfb, edge, 0, 0, -math.MaxFloat64, margin)
+	}
+	edge := featureBetween(cache.indexB[0], cache.indexB[1]).Index
+	return clipPolygons(c, &polyA, xfa, &polyB, xfb, 0, -math.MaxFloat64, edge, 0, margin)
+}
+
+// asPolygon returns the shape as a polygon, storing the points of boxes in
+// points. It reports whether the shape is a polygon or a box.
+func asPolygon(s Shape, points *[4]Point) (Polygon, bool) {
+	switch s := s.(type) {
+	case *Polygon:
+		return *s, true
+	case *Box:
+		return s.polygon(points), true
+	}
+	return Polygon{}, false
+}
+
+// conservativeAdvancement computes the time of impact by repeatedly
+// advancing time by the largest step over which the shapes cannot meet,
+// given a bound on the rate at which the distance between them decreases.
+func conservativeAdvancement(input *TOIInput, settings *Settings, bound float64) TOIOutput {
+	a, b := input.A, input.B
+	tolerance := settings.TOITolerance
+
+	// Scaled shapes are updated in place at each step
+	var proxyA, proxyB *scaledShape
+	if input.scaled() {
+		proxyA = &scaledShape{shape: a, center: input.SweepA.LocalCenter}
+		proxyB = &scaledShape{shape: b, center: input.SweepB.LocalCenter}
+		a, b = proxyA, proxyB
+	}
+
+	var output TOIOutput
+	var simplex Simplex
+	var cache SimplexCache
+	t := 0.0
+	for output.State == TOIUnknown {
+		xfa, xfb := input.getTransforms(t)
+		if proxyA != nil {
+			proxyA.scale, proxyB.scale = input.getScales(t)
+		}
+		simplex.ReadCache(&cache, a, xfa, b, xfb)
+		simplex.GJK(a, xfa, b, xfb)
+		simplex.WriteCache(&cache)
+		distance := simplex.ClosestPoint().Length()
+
+		// The target depends on the radii, which may change with the scale
+		target := math.Max(settings.LinearSlop, a.GetRadius()+b.GetRadius()+settings.TOITarget)
+
+		switch {
+		case distance <= 0:
+			// The shapes can only overlap at the start
+			output.State = TOIOverlapped
+			output.Time = t
+		case distance < target+tolerance:
+			// Victory!
+			output.State = TOIHit
+			output.Time = t
+			output.Normal = simplex.ClosestPoint().Div(distance)
+		default:
+			// The shapes cannot close the gap any faster than the bound
+			t += (distance - target) / bound
+			if t >= 1 {
+				output.State = TOISeparated
+				output.Time = 1
+			}
+		}
+
+		output.Iterations++
+		if output.State == TOIUnknown && output.Iterations >= settings.TOIMaxIterations {
+			output.State = TOIFailed
+			output.Time = t
+		}
+	}
+	return output
+}
+
+// linearPath returns the positions in world space at times 0 and 1 of the
+// local point p moving along the sweep. It reports whether p moves along a
+// straight line, which is the case if the sweep does not rotate or p is
+// the center of mass.
+func (s Sweep) linearPath(p Point) (Point, Point, bool) {
+	if s.R0 != s.R1 && p != s.LocalCenter {
+		return Point{}, Point{}, false
+	}
+	return s.GetTransform(0).Mul(p), s.GetTransform(1).Mul(p), true
+}
+
+// analyticTimeOfImpact computes the time of impact in closed form for
+// circles whose centers translate along their sweeps, and polygons that do
+// not rotate. It reports whether the shapes and sweeps support it.
+func analyticTimeOfImpact(input *TOIInput, target, tolerance float64) (TOIOutput, bool) {
+	if input.MotionA != nil || input.MotionB != nil {
+		return TOIOutput{}, false
+	}
+
+	var points [4]Point
+	switch a := input.A.(type) {
+	case *Circle:
+		var poly *Polygon
+		switch b := input.B.(type) {
+		case *Circle:
+			return circlesTimeOfImpact(a, input.SweepA, b, input.SweepB, target, tolerance)
+		case *Polygon:
+			poly = b
+		case *Box:
+			p := b.polygon(&points)
+			poly = &p
+		default:
+			return TOIOutput{}, false
+		}
+		output, ok := polygonAndCircleTimeOfImpact(poly, input.SweepB, a, input.SweepA, target, tolerance)
+		output.Normal = output.Normal.Neg()
+		return output, ok
+	case *Polygon:
+		if b, ok := input.B.(*Circle); ok {
+			return polygonAndCircleTimeOfImpact(a, input.SweepA, b, input.SweepB, target, tolerance)
+		}
+	case *Box:
+		if b, ok := input.B.(*Circle); ok {
+			poly := a.polygon(&points)
+			return polygonAndCircleTimeOfImpact(&poly, input.SweepA, b, input.SweepB, target, tolerance)
+		}
+	}
+	return TOIOutput{}, false
+}
+
+// circlesTimeOfImpact computes the time of impact of two circles by solving
+// for the time at which the distance between their centers reaches target.
+func circlesTimeOfImpact(a *Circle, sweepA Sweep, b *Circle, sweepB Sweep, target, tolerance float64) (TOIOutput, bool) {
+	a0, a1, okA := sweepA.linearPath(a.Center)
+	b0, b1, okB := sweepB.linearPath(b.Center)
+	if !okA || !okB {
+		return TOIOutput{}, false
+	}
+
+	// Compute the relative position and motion of the centers
+	p := b0.Sub(a0)
+	v := b1.Sub(a1).Sub(p)
+
+	distance := p.Length()
+	if distance <= 0 {
+		return TOIOutput{State: TOIOverlapped}, true
+	}
+	if distance < target+tolerance {
+		return TOIOutput{State: TOIHit, Normal: p.Div(distance)}, true
+	}
+
+	t, ok := sweepCircle(p, v, target)
+	if !ok {
+		return TOIOutput{State: TOISeparated, Time: 1}, true
+	}
+	return TOIOutput{
+		State:  TOIHit,
+		Time:   t,
+		Normal: p.Add(v.Mul(t)).Normalize(),
+	}, true
+}
+
+// polygonAndCircleTimeOfImpact computes the time of impact of a polygon and
+// a circle by sweeping the center of the circle against the edges and
+// vertices of the polygon, expanded by target.
+func polygonAndCircleTimeOfImpact(a *Polygon, sweepA Sweep, b *Circle, sweepB Sweep, target, tolerance float64) (TOIOutput, bool) {
+	b0, b1, ok := sweepB.linearPath(b.Center)
+	if sweepA.R0 != sweepA.R1 || !ok {
+		return TOIOutput{}, false
+	}
+
+	// Compute the motion of the circle in the frame of the polygon,
+	// which only translates
+	xf0, xf1 := sweepA.GetTransform(0), sweepA.GetTransform(1)
+	p := xf0.MulT(b0)
+	v := xf1.MulT(b1).Sub(p)
+
+	feature, point, separation := a.closestFeature(p)
+	if separation <= 0 {
+		return TOIOutput{State: TOIOverlapped}, true
+	}
+	if separation < target+tolerance {
+		normal := p.Sub(point).Div(separation)
+		if feature.Type == FeatureEdge {
+			normal = a.Normals[feature.Index]
+		}
+		return TOIOutput{State: TOIHit, Normal: xf0.Rotation.Mul(normal)}, true
+	}
+
+	// Find the first contact with the edges
+	best := math.MaxFloat64
+	var normal Point
+	for i := range a.Points {
+		n := a.Normals[i]
+		speed := Dot(n, v)
+		distance := Dot(n, p.Sub(a.Points[i])) - target
+		if speed >= 0 || distance < 0 {
+			// Moving away from the edge or already past it
+			continue
+		}
+
+		t := distance / -speed
+		if t >= best {
+			continue
+		}
+
+		// Check that the contact is within the edge
+		j := i + 1
+		if j == len(a.Points) {
+			j = 0
+		}
+		edge := a.Points[j].Sub(a.Points[i])
+		u := Dot(p.Add(v.Mul(t)).Sub(a.Points[i]), edge)
+		if u >= 0 && u <= Dot(edge, edge) {
+			best = t
+			normal = n
+		}
+	}
+
+	// Find the first contact with the vertices
+	for i := range a.Points {
+		d := p.Sub(a.Points[i])
+		if t, ok := sweepCircle(d, v, target); ok && t < best {
+			best = t
+			normal = d.Add(v.Mul(t)).Normalize()
+		}
+	}
+
+	if best > 1 {
+		return TOIOutput{State: TOISeparated, Time: 1}, true
+	}
+	return TOIOutput{
+		State:  TOIHit,
+		Time:   best,
+		Normal: xf0.Rotation.Mul(normal),
+	}, true
+}
+
+// sweepCircle returns the earliest time in [0, 1] at which the point p+t*v,
+// initially outside the circle of radius r about the origin, reaches the
+// circle. It reports whether there is such a time.
+func sweepCircle(p, v Point, r float64) (float64, bool) {
+	// Solve |p + t * v|^2 = r^2
+	a := Dot(v, v)
+	b := Dot(p, v)
+	c := Dot(p, p) - r*r
+	if a == 0 || b >= 0 {
+		// Not approaching the circle
+		return 0, false
+	}
+
+	disc := b*b - a*c
+	if disc < 0 {
+		// The line misses the circle
+		return 0, false
+	}
+
+	t := (-b - math.Sqrt(disc)) / a
+	if t < 0 || t > 1 {
+		return 0, false
 	}
-	return t1
+	return t, true
 }
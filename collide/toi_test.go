@@ -0,0 +1,384 @@
+package collide
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// coreDistance returns the distance between the cores of the shapes of the
+// input at time t.
+func coreDistance(input *TOIInput, t float64) float64 {
+	a, b := input.getShapes(t)
+	xfa, xfb := input.getTransforms(t)
+	output := ShapeDistance(&DistanceInput{A: a, B: b, TransformA: xfa, TransformB: xfb})
+	return output.Distance
+}
+
+func TestTimeOfImpact(t *testing.T) {
+	settings := MeterSettings()
+	square := Rectangle(Point{}, Point{1, 1})
+	rounded := &capsule{Point{-1, 0}, Point{1, 0}, 0.5}
+	tests := []struct {
+		name   string
+		input  TOIInput
+		state  TOIState
+		time   float64 // approximate time of impact
+		normal Point
+	}{
+		{
+			name: "head on",
+			input: TOIInput{
+				A:      square,
+				B:      square,
+				SweepB: Sweep{P0: Point{10, 0}, P1: Point{-10, 0}},
+			},
+			state:  TOIHit,
+			time:   0.4,
+			normal: Point{1, 0},
+		},
+		{
+			name: "both moving",
+			input: TOIInput{
+				A:      square,
+				B:      square,
+				SweepA: Sweep{P0: Point{0, -5}, P1: Point{0, 5}},
+				SweepB: Sweep{P0: Point{0.5, 5}, P1: Point{0.5, -5}},
+			},
+			state:  TOIHit,
+			time:   0.4,
+			normal: Point{0, 1},
+		},
+		{
+			name: "rotating",
+			input: TOIInput{
+				A:      Rectangle(Point{}, Point{4, 0.25}),
+				B:      square,
+				SweepA: Sweep{R1: math.Pi / 2},
+				SweepB: Sweep{P0: Point{0, 3}, P1: Point{0, 3}},
+			},
+			state: TOIHit,
+		},
+		{
+			name: "rounded shapes",
+			input: TOIInput{
+				A:      rounded,
+				B:      rounded,
+				SweepB: Sweep{P0: Point{0, 5}, P1: Point{0, -5}, R0: 0.5, R1: 0.5},
+			},
+			state: TOIHit,
+		},
+		{
+			name: "separated",
+			input: TOIInput{
+				A:      square,
+				B:      square,
+				SweepB: Sweep{P0: Point{10, 0}, P1: Point{5, 0}},
+			},
+			state: TOISeparated,
+			time:  1,
+		},
+		{
+			name: "passing by",
+			input: TOIInput{
+				A:      square,
+				B:      square,
+				SweepB: Sweep{P0: Point{-10, 3}, P1: Point{10, 3}},
+			},
+			state: TOISeparated,
+			time:  1,
+		},
+		{
+			name: "overlapped",
+			input: TOIInput{
+				A:      square,
+				B:      square,
+				SweepB: Sweep{P0: Point{1, 0}, P1: Point{5, 0}},
+			},
+			state: TOIOverlapped,
+			time:  0,
+		},
+	}
+	for _, test := range tests {
+		output := TimeOfImpact(&test.input)
+		if output.State != test.state {
+			t.Errorf("%s: got state %v, want %v", test.name, output.State, test.state)
+			continue
+		}
+		if output.State != TOIHit {
+			if output.Time != test.time {
+				t.Errorf("%s: got time %v, want %v", test.name, output.Time, test.time)
+			}
+			continue
+		}
+
+		// The cores are at the target separation at the time of impact
+		radius := test.input.A.GetRadius() + test.input.B.GetRadius()
+		target := math.Max(settings.LinearSlop, radius+settings.TOITarget)
+		distance := coreDistance(&test.input, output.Time)
+		if math.Abs(distance-target) > settings.TOITolerance {
+			t.Errorf("%s: got distance %v at the time of impact, want %v", test.name, distance, target)
+		}
+		if test.time != 0 && math.Abs(output.Time-test.time) > 0.01 {
+			t.Errorf("%s: got time %v, want about %v", test.name, output.Time, test.time)
+		}
+		if !test.normal.IsZero() && Dot(output.Normal, test.normal) < 1-1e-6 {
+			t.Errorf("%s: got normal %v, want %v", test.name, output.Normal, test.normal)
+		}
+	}
+}
+
+func TestTimeOfImpactSettings(t *testing.T) {
+	// The target separation scales with the settings
+	square := Rectangle(Point{}, Point{20, 20})
+	pixels := PixelSettings()
+	input := TOIInput{
+		A:        square,
+		B:        square,
+		SweepB:   Sweep{P0: Point{100, 0}, P1: Point{-100, 0}},
+		Settings: &pixels,
+	}
+	output := TimeOfImpact(&input)
+	if output.State != TOIHit {
+		t.Fatalf("got state %v, want %v", output.State, TOIHit)
+	}
+	distance := coreDistance(&input, output.Time)
+	if math.Abs(distance-pixels.LinearSlop) > pixels.TOITolerance {
+		t.Errorf("got distance %v, want %v", distance, pixels.LinearSlop)
+	}
+
+	// The solver gives up after the maximum number of iterations
+	settings := MeterSettings()
+	settings.TOIMaxIterations = 1
+	input = TOIInput{
+		A:        Rectangle(Point{}, Point{4, 0.25}),
+		B:        Rectangle(Point{}, Point{1, 1}),
+		SweepA:   Sweep{R1: math.Pi / 2},
+		SweepB:   Sweep{P0: Point{0, 3}, P1: Point{0, 3}},
+		Settings: &settings,
+	}
+	if output := TimeOfImpact(&input); output.State != TOIFailed || output.Iterations != 1 {
+		t.Errorf("got state %v after %d iterations, want %v after 1", output.State, output.Iterations, TOIFailed)
+	}
+}
+
+func TestTimeOfImpactAnalytic(t *testing.T) {
+	// Translating circles take the exact closed form paths
+	circle := &Circle{Radius: 1}
+	small := &Circle{Radius: 0.5}
+	square := Rectangle(Point{}, Point{1, 1})
+	box := &Box{Extents: Point{1, 1}}
+	corner := (9 - 0.485/math.Sqrt2) / 20
+	tests := []struct {
+		name   string
+		input  TOIInput
+		time   float64
+		normal Point
+	}{
+		{
+			name: "circles",
+			input: TOIInput{
+				A:      circle,
+				B:      circle,
+				SweepB: Sweep{P0: Point{10, 0}, P1: Point{-10, 0}},
+			},
+			time:   (10 - 1.985) / 20,
+			normal: Point{1, 0},
+		},
+		{
+			name: "polygon edge and circle",
+			input: TOIInput{
+				A:      square,
+				B:      small,
+				SweepB: Sweep{P0: Point{10, 0.5}, P1: Point{-10, 0.5}},
+			},
+			time:   (10 - 1.485) / 20,
+			normal: Point{1, 0},
+		},
+		{
+			name: "polygon vertex and circle",
+			input: TOIInput{
+				A:      square,
+				B:      small,
+				SweepB: Sweep{P0: Point{10, 10}, P1: Point{-10, -10}},
+			},
+			time:   corner,
+			normal: Point{math.Sqrt2 / 2, math.Sqrt2 / 2},
+		},
+		{
+			name: "circle and moving polygon",
+			input: TOIInput{
+				A:      small,
+				B:      square,
+				SweepB: Sweep{P0: Point{10, 10}, P1: Point{-10, -10}},
+			},
+			time:   corner,
+			normal: Point{math.Sqrt2 / 2, math.Sqrt2 / 2},
+		},
+		{
+			name: "rotated box and circle",
+			input: TOIInput{
+				A:      box,
+				B:      small,
+				SweepA: Sweep{R0: math.Pi / 2, R1: math.Pi / 2},
+				SweepB: Sweep{P0: Point{0.5, -10}, P1: Point{0.5, 10}},
+			},
+			time:   (10 - 1.485) / 20,
+			normal: Point{0, -1},
+		},
+	}
+	for _, test := range tests {
+		output := TimeOfImpact(&test.input)
+		if output.State != TOIHit {
+			t.Errorf("%s: got state %v, want %v", test.name, output.State, TOIHit)
+			continue
+		}
+		if output.Iterations != 0 {
+			t.Errorf("%s: got %d iterations, want the analytic path", test.name, output.Iterations)
+		}
+		if !approxEqual(output.Time, test.time) {
+			t.Errorf("%s: got time %v, want %v", test.name, output.Time, test.time)
+		}
+		if !approxEqualPoint(output.Normal, test.normal) {
+			t.Errorf("%s: got normal %v, want %v", test.name, output.Normal, test.normal)
+		}
+	}
+}
+
+func TestTimeOfImpactAnalyticRandom(t *testing.T) {
+	// The analytic paths find the first time the cores reach the target
+	r := rand.New(rand.NewSource(1))
+	randomPoint := func(size float64) Point {
+		return Point{(r.Float64() - 0.5) * size, (r.Float64() - 0.5) * size}
+	}
+	shapes := []Shape{
+		&Circle{Radius: 0.5},
+		&Circle{Center: Point{0.3, 0}, Radius: 0.4},
+		Rect(0, 0, 1, 2),
+		&Box{Extents: Point{0.5, 0.3}},
+		NewPolygon(Point{0, 0}, Point{1, 0}, Point{0, 1}),
+	}
+	settings := MeterSettings()
+	const eps = 1e-9
+	for i := 0; i < 2000; i++ {
+		a, b := shapes[r.Intn(len(shapes))], shapes[r.Intn(len(shapes))]
+		ra, rb := r.Float64()*3, r.Float64()*3
+		input := TOIInput{
+			A:      a,
+			B:      b,
+			SweepA: Sweep{P0: randomPoint(10), P1: randomPoint(10), R0: ra, R1: ra},
+			SweepB: Sweep{P0: randomPoint(10), P1: randomPoint(10), R0: rb, R1: rb},
+		}
+		target := math.Max(settings.LinearSlop, a.GetRadius()+b.GetRadius()+settings.TOITarget)
+		output, ok := analyticTimeOfImpact(&input, target, settings.TOITolerance)
+		if !ok || output.Time == 0 {
+			// Shapes within the target at the start hit at time zero
+			continue
+		}
+		if output.State == TOIHit {
+			if distance := coreDistance(&input, output.Time); !approxEqual(distance, target) {
+				t.Fatalf("%T and %T: got distance %v at the time of impact, want %v", a, b, distance, target)
+			}
+		}
+		for j := 0; j < 100; j++ {
+			time := output.Time * float64(j) / 100
+			if distance := coreDistance(&input, time); distance < target-eps {
+				t.Fatalf("%T and %T: got distance %v at time %v before the time of impact %v", a, b, distance, time, output.Time)
+			}
+		}
+	}
+}
+
+func TestTimeOfImpactManifold(t *testing.T) {
+	settings := MeterSettings()
+	square := Rect(0, 0, 2, 2)
+	box := &Box{Extents: Point{1, 1}}
+	circle := &Circle{Radius: 1}
+	tests := []struct {
+		name        string
+		a, b        Shape
+		sweepB      Sweep
+		state       TOIState
+		normal      Point
+		separations []float64
+	}{
+		{
+			name:        "polygon faces",
+			a:           square,
+			b:           square,
+			sweepB:      Sweep{P0: Point{10, 0.5}, P1: Point{-10, 0.5}},
+			state:       TOIHit,
+			normal:      Point{1, 0},
+			separations: []float64{settings.LinearSlop, settings.LinearSlop},
+		},
+		{
+			name:        "polygon and box faces",
+			a:           square,
+			b:           box,
+			sweepB:      Sweep{P0: Point{0.5, -10}, P1: Point{0.5, 10}},
+			state:       TOIHit,
+			normal:      Point{0, -1},
+			separations: []float64{settings.LinearSlop, settings.LinearSlop},
+		},
+		{
+			name:        "polygon and rotated polygon",
+			a:           square,
+			b:           square,
+			sweepB:      Sweep{P0: Point{10, 0}, P1: Point{-10, 0}, R0: math.Pi / 4, R1: math.Pi / 4},
+			state:       TOIHit,
+			normal:      Point{1, 0},
+			separations: []float64{settings.LinearSlop},
+		},
+		{
+			name:        "polygon and circle",
+			a:           square,
+			b:           circle,
+			sweepB:      Sweep{P0: Point{10, 0.5}, P1: Point{-10, 0.5}},
+			state:       TOIHit,
+			normal:      Point{1, 0},
+			separations: []float64{settings.TOITarget},
+		},
+		{
+			name:   "separated",
+			a:      square,
+			b:      square,
+			sweepB: Sweep{P0: Point{10, 0}, P1: Point{10, 10}},
+			state:  TOISeparated,
+		},
+	}
+	for _, test := range tests {
+		c := Collision{Count: 2}
+		input := TOIInput{A: test.a, B: test.b, SweepB: test.sweepB}
+		output := TimeOfImpactManifold(&input, &c)
+		if output.State != test.state {
+			t.Errorf("%s: got state %v, want %v", test.name, output.State, test.state)
+			continue
+		}
+		if c.Count != len(test.separations) {
+			t.Errorf("%s: got %d points, want %d", test.name, c.Count, len(test.separations))
+			continue
+		}
+		if c.Count == 0 {
+			continue
+		}
+		if !approxEqualPoint(c.Normal, test.normal) {
+			t.Errorf("%s: got normal %v, want %v", test.name, c.Normal, test.normal)
+		}
+		for i, separation := range test.separations {
+			if math.Abs(c.Points[i].Separation-separation) > settings.TOITolerance {
+				t.Errorf("%s: got separation %v, want %v", test.name, c.Points[i].Separation, separation)
+			}
+		}
+	}
+
+	// Overlapped shapes get their collision at time zero
+	var c Collision
+	input := TOIInput{A: square, B: square, SweepB: Sweep{P0: Point{1.5, 0}, P1: Point{10, 0}}}
+	if output := TimeOfImpactManifold(&input, &c); output.State != TOIOverlapped {
+		t.Fatalf("got state %v, want %v", output.State, TOIOverlapped)
+	}
+	want := Collide(square, NewTransform(Point{}, 0), square, NewTransform(Point{1.5, 0}, 0))
+	if !sameCollision(&c, want) {
+		t.Errorf("got collision %v, want %v", c, *want)
+	}
+}
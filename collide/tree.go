@@ -0,0 +1,681 @@
+package collide
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// nullNode is the index of a missing node.
+const nullNode = -1
+
+// treeStackSize is the size of the traversal stack that does not allocate.
+const treeStackSize = 64
+
+// treeNode is a node of a DynamicTree. Leaves hold proxies.
+type treeNode struct {
+	aabb     AABB
+	userData interface{}
+
+	// parent is the index of the parent node, or the next free node
+	// if the node is not in use
+	parent int
+	child1 int
+	child2 int
+
+	// height is zero for leaves and -1 for free nodes
+	height int
+	moved  bool
+}
+
+// isLeaf reports whether the node is a leaf.
+func (n *treeNode) isLeaf() bool {
+	return n.child1 == nullNode
+}
+
+// A DynamicTree is a broadphase based on a bounding volume hierarchy, in the
+// style of b2DynamicTree from Box2D. Each proxy is a leaf holding an enlarged
+// bounding box and user data. The enlarged boxes allow proxies to move a
+// little without updating the tree. The tree is kept balanced by rotations,
+// so queries take logarithmic time.
+//
+// Proxies are identified by the integer returned by CreateProxy, which stays
+// valid until the proxy is destroyed.
+type DynamicTree struct {
+	root     int
+	nodes    []treeNode
+	free     int
+	proxies  int
+	settings Settings
+}
+
+// NewDynamicTree returns an empty tree. The bounding boxes of proxies are
+// enlarged according to the settings, or MeterSettings if settings is nil.
+func NewDynamicTree(settings *Settings) *DynamicTree {
+	return &DynamicTree{
+		root:     nullNode,
+		free:     nullNode,
+		settings: settingsOrDefault(settings),
+	}
+}
+
+// allocateNode returns the index of an unused node.
+// It may reallocate the nodes, invalidating pointers to them.
+func (t *DynamicTree) allocateNode() int {
+	if t.free == nullNode {
+		t.nodes = append(t.nodes, treeNode{})
+		t.free = len(t.nodes) - 1
+		t.nodes[t.free].parent = nullNode
+	}
+	id := t.free
+	t.free = t.nodes[id].parent
+	t.nodes[id] = treeNode{
+		parent: nullNode,
+		child1: nullNode,
+		child2: nullNode,
+	}
+	return id
+}
+
+// freeNode returns a node to the free list.
+func (t *DynamicTree) freeNode(id int) {
+	t.nodes[id] = treeNode{
+		parent: t.free,
+		child1: nullNode,
+		child2: nullNode,
+		height: -1,
+	}
+	t.free = id
+}
+
+// CreateProxy creates a proxy in the tree with the bounding box enlarged by
+// the AABB margin, and returns its id.
+func (t *DynamicTree) CreateProxy(aabb AABB, userData interface{}) int {
+	id := t.allocateNode()
+	n := &t.nodes[id]
+	n.aabb = aabb.Expand(t.settings.AABBMargin)
+	n.userData = userData
+	n.moved = true
+	t.insertLeaf(id)
+	t.proxies++
+	return id
+}
+
+// DestroyProxy removes a proxy from the tree.
+func (t *DynamicTree) DestroyProxy(id int) {
+	if !t.isProxy(id) {
+		panic("collide: invalid proxy")
+	}
+	t.removeLeaf(id)
+	t.freeNode(id)
+	t.proxies--
+}
+
+// MoveProxy updates the bounding box of a proxy that moved by displacement.
+// The tree is only updated if the bounding box is no longer contained by the
+// enlarged one, in which case the enlarged box is extended in the direction
+// of the displacement. MoveProxy reports whether the tree was updated.
+func (t *DynamicTree) MoveProxy(id int, aabb AABB, displacement Point) bool {
+	if !t.isProxy(id) {
+		panic("collide: invalid proxy")
+	}
+
+	// Predict the motion of the proxy
+	fat := aabb.Expand(t.settings.AABBMargin)
+	d := displacement.Mul(t.settings.AABBMultiplier)
+	if d.X < 0 {
+		fat.Min.X += d.X
+	} else {
+		fat.Max.X += d.X
+	}
+	if d.Y < 0 {
+		fat.Min.Y += d.Y
+	} else {
+		fat.Max.Y += d.Y
+	}
+
+	treeAABB := t.nodes[id].aabb
+	if treeAABB.Contains(aabb) {
+		// Keep the box unless it became too large, since a large box
+		// generates many false positives.
+		huge := fat.Expand(4 * t.settings.AABBMargin)
+		if huge.Contains(treeAABB) {
+			return false
+		}
+	}
+
+	t.removeLeaf(id)
+	t.nodes[id].aabb = fat
+	t.insertLeaf(id)
+	t.nodes[id].moved = true
+	return true
+}
+
+// isProxy reports whether id is the id of a proxy.
+func (t *DynamicTree) isProxy(id int) bool {
+	return id >= 0 && id < len(t.nodes) && t.nodes[id].height == 0
+}
+
+// UserData returns the user data of a proxy.
+func (t *DynamicTree) UserData(id int) interface{} {
+	if !t.isProxy(id) {
+		panic("collide: invalid proxy")
+	}
+	return t.nodes[id].userData
+}
+
+// FatAABB returns the enlarged bounding box of a proxy.
+func (t *DynamicTree) FatAABB(id int) AABB {
+	if !t.isProxy(id) {
+		panic("collide: invalid proxy")
+	}
+	return t.nodes[id].aabb
+}
+
+// Moved reports whether the tree was updated for a proxy since it was
+// created or ClearMoved was called. A broadphase uses it to find the proxies
+// whose pairs must be updated.
+func (t *DynamicTree) Moved(id int) bool {
+	if !t.isProxy(id) {
+		panic("collide: invalid proxy")
+	}
+	return t.nodes[id].moved
+}
+
+// ClearMoved clears the moved flag of a proxy.
+func (t *DynamicTree) ClearMoved(id int) {
+	if !t.isProxy(id) {
+		panic("collide: invalid proxy")
+	}
+	t.nodes[id].moved = false
+}
+
+// ProxyCount returns the number of proxies in the tree.
+func (t *DynamicTree) ProxyCount() int {
+	return t.proxies
+}
+
+// insertLeaf inserts a leaf next to the sibling that minimizes the increase
+// in the perimeters of the nodes.
+func (t *DynamicTree) insertLeaf(leaf int) {
+	if t.root == nullNode {
+		t.root = leaf
+		t.nodes[leaf].parent = nullNode
+		return
+	}
+
+	// Find the best sibling for this node
+	leafAABB := t.nodes[leaf].aabb
+	index := t.root
+	for !t.nodes[index].isLeaf() {
+		n := &t.nodes[index]
+		area := n.aabb.Perimeter()
+		combinedArea := n.aabb.Union(leafAABB).Perimeter()
+
+		// Cost of creating a new parent for this node and the new leaf
+		cost := 2 * combinedArea
+
+		// Minimum cost of pushing the leaf further down the tree
+		inheritanceCost := 2 * (combinedArea - area)
+		cost1 := t.descendCost(n.child1, leafAABB) + inheritanceCost
+		cost2 := t.descendCost(n.child2, leafAABB) + inheritanceCost
+
+		// Descend according to the minimum cost
+		if cost < cost1 && cost < cost2 {
+			break
+		}
+		if cost1 < cost2 {
+			index = n.child1
+		} else {
+			index = n.child2
+		}
+	}
+	sibling := index
+
+	// Create a new parent
+	oldParent := t.nodes[sibling].parent
+	newParent := t.allocateNode()
+	t.nodes[newParent].parent = oldParent
+	t.nodes[newParent].aabb = leafAABB.Union(t.nodes[sibling].aabb)
+	t.nodes[newParent].height = t.nodes[sibling].height + 1
+	t.nodes[newParent].child1 = sibling
+	t.nodes[newParent].child2 = leaf
+	t.nodes[sibling].parent = newParent
+	t.nodes[leaf].parent = newParent
+	if oldParent != nullNode {
+		t.replaceChild(oldParent, sibling, newParent)
+	} else {
+		t.root = newParent
+	}
+
+	// Walk back up the tree fixing heights and bounding boxes
+	t.refit(t.nodes[leaf].parent)
+}
+
+// descendCost returns the cost of descending into the child to insert a leaf
+// with the given bounding box.
+func (t *DynamicTree) descendCost(child int, leafAABB AABB) float64 {
+	n := &t.nodes[child]
+	area := n.aabb.Union(leafAABB).Perimeter()
+	if n.isLeaf() {
+		return area
+	}
+	return area - n.aabb.Perimeter()
+}
+
+// replaceChild replaces the child of a parent node.
+func (t *DynamicTree) replaceChild(parent, oldChild, newChild int) {
+	if t.nodes[parent].child1 == oldChild {
+		t.nodes[parent].child1 = newChild
+	} else {
+		t.nodes[parent].child2 = newChild
+	}
+}
+
+// refit balances the ancestors of a node, starting with the node itself,
+// and updates their heights and bounding boxes.
+func (t *DynamicTree) refit(index int) {
+	for index != nullNode {
+		index = t.balance(index)
+		n := &t.nodes[index]
+		c1 := &t.nodes[n.child1]
+		c2 := &t.nodes[n.child2]
+		n.height = 1 + maxInt(c1.height, c2.height)
+		n.aabb = c1.aabb.Union(c2.aabb)
+		index = n.parent
+	}
+}
+
+// removeLeaf removes a leaf from the tree and frees its parent.
+func (t *DynamicTree) removeLeaf(leaf int) {
+	if leaf == t.root {
+		t.root = nullNode
+		return
+	}
+
+	parent := t.nodes[leaf].parent
+	grandParent := t.nodes[parent].parent
+	sibling := t.nodes[parent].child1
+	if sibling == leaf {
+		sibling = t.nodes[parent].child2
+	}
+
+	// Connect the sibling to the grandparent and destroy the parent
+	t.freeNode(parent)
+	t.nodes[sibling].parent = grandParent
+	if grandParent == nullNode {
+		t.root = sibling
+		return
+	}
+	t.replaceChild(grandParent, parent, sibling)
+	t.refit(grandParent)
+}
+
+// balance performs a left or right rotation if node A is imbalanced,
+// and returns the index of the new root of the subtree.
+func (t *DynamicTree) balance(iA int) int {
+	A := &t.nodes[iA]
+	if A.isLeaf() || A.height < 2 {
+		return iA
+	}
+
+	iB, iC := A.child1, A.child2
+	B, C := &t.nodes[iB], &t.nodes[iC]
+	balance := C.height - B.height
+
+	// Rotate C up
+	if balance > 1 {
+		iF, iG := C.child1, C.child2
+		F, G := &t.nodes[iF], &t.nodes[iG]
+
+		// Swap A and C
+		C.child1 = iA
+		C.parent = A.parent
+		A.parent = iC
+		if C.parent != nullNode {
+			t.replaceChild(C.parent, iA, iC)
+		} else {
+			t.root = iC
+		}
+
+		// Rotate
+		if F.height > G.height {
+			C.child2 = iF
+			A.child2 = iG
+			G.parent = iA
+			A.aabb = B.aabb.Union(G.aabb)
+			C.aabb = A.aabb.Union(F.aabb)
+			A.height = 1 + maxInt(B.height, G.height)
+			C.height = 1 + maxInt(A.height, F.height)
+		} else {
+			C.child2 = iG
+			A.child2 = iF
+			F.parent = iA
+			A.aabb = B.aabb.Union(F.aabb)
+			C.aabb = A.aabb.Union(G.aabb)
+			A.height = 1 + maxInt(B.height, F.height)
+			C.height = 1 + maxInt(A.height, G.height)
+		}
+		return iC
+	}
+
+	// Rotate B up
+	if balance < -1 {
+		iD, iE := B.child1, B.child2
+		D, E := &t.nodes[iD], &t.nodes[iE]
+
+		// Swap A and B
+		B.child1 = iA
+		B.parent = A.parent
+		A.parent = iB
+		if B.parent != nullNode {
+			t.replaceChild(B.parent, iA, iB)
+		} else {
+			t.root = iB
+		}
+
+		// Rotate
+		if D.height > E.height {
+			B.child2 = iD
+			A.child1 = iE
+			E.parent = iA
+			A.aabb = C.aabb.Union(E.aabb)
+			B.aabb = A.aabb.Union(D.aabb)
+			A.height = 1 + maxInt(C.height, E.height)
+			B.height = 1 + maxInt(A.height, D.height)
+		} else {
+			B.child2 = iE
+			A.child1 = iD
+			D.parent = iA
+			A.aabb = C.aabb.Union(D.aabb)
+			B.aabb = A.aabb.Union(E.aabb)
+			A.height = 1 + maxInt(C.height, D.height)
+			B.height = 1 + maxInt(A.height, E.height)
+		}
+		return iB
+	}
+
+	return iA
+}
+
+// Query calls callback with the id of each proxy whose enlarged bounding box
+// overlaps aabb. The query stops if callback returns false.
+// The tree must not be modified by callback.
+func (t *DynamicTree) Query(aabb AABB, callback func(id int) bool) {
+	var buf [treeStackSize]int
+	stack := append(buf[:0], t.root)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == nullNode {
+			continue
+		}
+
+		n := &t.nodes[id]
+		if !n.aabb.Overlaps(aabb) {
+			continue
+		}
+		if n.isLeaf() {
+			if !callback(id) {
+				return
+			}
+		} else {
+			stack = append(stack, n.child1, n.child2)
+		}
+	}
+}
+
+// RayCast calls callback with the id of each proxy whose enlarged bounding
+// box may be hit by the ray, along with the ray clipped by earlier calls.
+// The return value of callback controls the query:
+//
+//	-1 ignores the proxy and continues with the same ray
+//	 0 terminates the query
+//	 a fraction clips the ray to that fraction of its direction
+//	 input.MaxFraction continues with the same ray
+//
+// Callbacks typically cast the ray against the shape of the proxy with
+// RayCast and return the fraction of the hit, which finds the closest hit.
+// The tree must not be modified by callback.
+func (t *DynamicTree) RayCast(input *RayCastInput, callback func(input RayCastInput, id int) float64) {
+	p := input.Origin
+	d := input.Direction
+	if d.IsZero() {
+		return
+	}
+
+	// The ray is separated from a box if the distance of the box from the
+	// ray's line exceeds the projection of its extents onto the line normal
+	v := CrossSP(1, d.Normalize())
+	absV := Point{math.Abs(v.X), math.Abs(v.Y)}
+
+	maxFraction := input.MaxFraction
+	segment := AABB{p, p}.addPoint(p.Add(d.Mul(maxFraction)))
+
+	var buf [treeStackSize]int
+	stack := append(buf[:0], t.root)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == nullNode {
+			continue
+		}
+
+		n := &t.nodes[id]
+		if !n.aabb.Overlaps(segment) {
+			continue
+		}
+		c := n.aabb.Center()
+		h := n.aabb.Extents()
+		if math.Abs(Dot(v, p.Sub(c)))-Dot(absV, h) > 0 {
+			continue
+		}
+
+		if !n.isLeaf() {
+			stack = append(stack, n.child1, n.child2)
+			continue
+		}
+
+		value := callback(RayCastInput{
+			Origin:      p,
+			Direction:   d,
+			MaxFraction: maxFraction,
+		}, id)
+		if value == 0 {
+			// The client has terminated the ray cast
+			return
+		}
+		if value > 0 {
+			// Update the segment bounding box
+			maxFraction = value
+			segment = AABB{p, p}.addPoint(p.Add(d.Mul(maxFraction)))
+		}
+	}
+}
+
+// Height returns the height of the tree, which is zero for a single proxy.
+func (t *DynamicTree) Height() int {
+	if t.root == nullNode {
+		return 0
+	}
+	return t.nodes[t.root].height
+}
+
+// MaxBalance returns the maximum difference between the heights of the
+// children of any node.
+func (t *DynamicTree) MaxBalance() int {
+	balance := 0
+	for i := range t.nodes {
+		n := &t.nodes[i]
+		if n.height <= 1 {
+			continue
+		}
+		b := t.nodes[n.child2].height - t.nodes[n.child1].height
+		if b < 0 {
+			b = -b
+		}
+		balance = maxInt(balance, b)
+	}
+	return balance
+}
+
+// AreaRatio returns the ratio of the sum of the perimeters of the nodes to
+// the perimeter of the root, which measures the quality of the tree.
+func (t *DynamicTree) AreaRatio() float64 {
+	if t.root == nullNode {
+		return 0
+	}
+	rootArea := t.nodes[t.root].aabb.Perimeter()
+	var totalArea float64
+	for i := range t.nodes {
+		if t.nodes[i].height >= 0 {
+			totalArea += t.nodes[i].aabb.Perimeter()
+		}
+	}
+	return totalArea / rootArea
+}
+
+// RebuildBottomUp rebuilds the tree by repeatedly pairing the two nodes
+// whose union has the smallest perimeter. It takes quadratic time, but
+// may produce a better tree than incremental insertion.
+func (t *DynamicTree) RebuildBottomUp() {
+	// Free the internal nodes and collect the leaves
+	var leaves []int
+	for i := range t.nodes {
+		n := &t.nodes[i]
+		if n.height < 0 {
+			continue
+		}
+		if n.isLeaf() {
+			n.parent = nullNode
+			leaves = append(leaves, i)
+		} else {
+			t.freeNode(i)
+		}
+	}
+
+	for len(leaves) > 1 {
+		// Find the pair with the smallest union
+		minCost := 0.0
+		iMin, jMin := -1, -1
+		for i := range leaves {
+			aabbi := t.nodes[leaves[i]].aabb
+			for j := i + 1; j < len(leaves); j++ {
+				cost := aabbi.Union(t.nodes[leaves[j]].aabb).Perimeter()
+				if iMin < 0 || cost < minCost {
+					iMin, jMin = i, j
+					minCost = cost
+				}
+			}
+		}
+
+		index1, index2 := leaves[iMin], leaves[jMin]
+		parent := t.allocateNode()
+		t.nodes[parent].child1 = index1
+		t.nodes[parent].child2 = index2
+		t.nodes[parent].height = 1 + maxInt(t.nodes[index1].height, t.nodes[index2].height)
+		t.nodes[parent].aabb = t.nodes[index1].aabb.Union(t.nodes[index2].aabb)
+		t.nodes[index1].parent = parent
+		t.nodes[index2].parent = parent
+
+		leaves[jMin] = leaves[len(leaves)-1]
+		leaves[iMin] = parent
+		leaves = leaves[:len(leaves)-1]
+	}
+
+	t.root = nullNode
+	if len(leaves) > 0 {
+		t.root = leaves[0]
+	}
+}
+
+// ShiftOrigin shifts the world origin of the tree, which is useful for
+// large worlds. The new origin is subtracted from every bounding box.
+func (t *DynamicTree) ShiftOrigin(newOrigin Point) {
+	for i := range t.nodes {
+		n := &t.nodes[i]
+		n.aabb.Min = n.aabb.Min.Sub(newOrigin)
+		n.aabb.Max = n.aabb.Max.Sub(newOrigin)
+	}
+}
+
+// Validate checks the structure and the metrics of the tree. It returns an
+// error describing the first inconsistency found.
+func (t *DynamicTree) Validate() error {
+	if t.root != nullNode && t.nodes[t.root].parent != nullNode {
+		return errors.New("collide: tree root has a parent")
+	}
+	count, err := t.validateNode(t.root)
+	if err != nil {
+		return err
+	}
+	if count != 2*t.proxies-1 && !(count == 0 && t.proxies == 0) {
+		return fmt.Errorf("collide: tree has %d nodes for %d proxies", count, t.proxies)
+	}
+
+	// Every node is either in the tree or free
+	free := 0
+	for i := t.free; i != nullNode; i = t.nodes[i].parent {
+		if t.nodes[i].height != -1 {
+			return fmt.Errorf("collide: free node %d is in use", i)
+		}
+		free++
+		if free > len(t.nodes) {
+			return errors.New("collide: tree free list has a cycle")
+		}
+	}
+	if count+free != len(t.nodes) {
+		return fmt.Errorf("collide: tree has %d nodes, %d in use and %d free", len(t.nodes), count, free)
+	}
+	return nil
+}
+
+// validateNode checks the subtree rooted at a node and returns the number of
+// nodes in it.
+func (t *DynamicTree) validateNode(id int) (int, error) {
+	if id == nullNode {
+		return 0, nil
+	}
+	n := &t.nodes[id]
+	if n.height < 0 {
+		return 0, fmt.Errorf("collide: tree node %d is free", id)
+	}
+	if n.isLeaf() {
+		if n.child2 != nullNode {
+			return 0, fmt.Errorf("collide: tree leaf %d has a child", id)
+		}
+		if n.height != 0 {
+			return 0, fmt.Errorf("collide: tree leaf %d has height %d", id, n.height)
+		}
+		return 1, nil
+	}
+
+	c1, c2 := &t.nodes[n.child1], &t.nodes[n.child2]
+	if c1.parent != id || c2.parent != id {
+		return 0, fmt.Errorf("collide: tree node %d is not the parent of its children", id)
+	}
+	if height := 1 + maxInt(c1.height, c2.height); n.height != height {
+		return 0, fmt.Errorf("collide: tree node %d has height %d, want %d", id, n.height, height)
+	}
+	if aabb := c1.aabb.Union(c2.aabb); n.aabb != aabb {
+		return 0, fmt.Errorf("collide: tree node %d does not bound its children", id)
+	}
+
+	count1, err := t.validateNode(n.child1)
+	if err != nil {
+		return 0, err
+	}
+	count2, err := t.validateNode(n.child2)
+	if err != nil {
+		return 0, err
+	}
+	return 1 + count1 + count2, nil
+}
+
+// maxInt returns the larger of a and b.
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,169 @@
+package collide
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// randomAABB returns a random bounding box within a square of the given size.
+func randomAABB(r *rand.Rand, size float64) AABB {
+	p := Point{r.Float64() * size, r.Float64() * size}
+	e := Point{r.Float64() * 2, r.Float64() * 2}
+	return AABB{p.Sub(e), p.Add(e)}
+}
+
+func TestDynamicTree(t *testing.T) {
+	// Random operations keep the tree valid, and queries agree with brute force
+	r := rand.New(rand.NewSource(1))
+	tree := NewDynamicTree(nil)
+	boxes := map[int]AABB{}
+	for step := 0; step < 1000; step++ {
+		switch k := r.Intn(10); {
+		case k < 4 || len(boxes) < 10:
+			aabb := randomAABB(r, 100)
+			id := tree.CreateProxy(aabb, step)
+			if tree.UserData(id) != step {
+				t.Fatalf("step %d: got user data %v, want %v", step, tree.UserData(id), step)
+			}
+			boxes[id] = aabb
+		case k < 5:
+			for id := range boxes {
+				tree.DestroyProxy(id)
+				delete(boxes, id)
+				break
+			}
+		default:
+			for id, aabb := range boxes {
+				d := Point{r.Float64()*4 - 2, r.Float64()*4 - 2}
+				aabb = AABB{aabb.Min.Add(d), aabb.Max.Add(d)}
+				tree.MoveProxy(id, aabb, d)
+				boxes[id] = aabb
+				break
+			}
+		}
+		if step%10 == 0 {
+			if err := tree.Validate(); err != nil {
+				t.Fatalf("step %d: %v", step, err)
+			}
+		}
+		if tree.ProxyCount() != len(boxes) {
+			t.Fatalf("step %d: got %d proxies, want %d", step, tree.ProxyCount(), len(boxes))
+		}
+		for id, aabb := range boxes {
+			if !tree.FatAABB(id).Contains(aabb) {
+				t.Fatalf("step %d: enlarged box %v does not contain %v", step, tree.FatAABB(id), aabb)
+			}
+		}
+
+		query := randomAABB(r, 100).Expand(5)
+		found := map[int]bool{}
+		tree.Query(query, func(id int) bool {
+			found[id] = true
+			return true
+		})
+		for id := range boxes {
+			if want := tree.FatAABB(id).Overlaps(query); found[id] != want {
+				t.Fatalf("step %d: got found %v for proxy %d, want %v", step, found[id], id, want)
+			}
+		}
+
+		// The closest hit agrees with casting against every box
+		input := RayCastInput{Origin: Point{r.Float64() * 100, -10}, Direction: Point{r.Float64() - 0.5, 1}, MaxFraction: 200}
+		want := input.MaxFraction
+		for _, aabb := range boxes {
+			if output := aabb.RayCast(&input); output.Hit && output.Fraction < want {
+				want = output.Fraction
+			}
+		}
+		got := input.MaxFraction
+		tree.RayCast(&input, func(input RayCastInput, id int) float64 {
+			output := boxes[id].RayCast(&input)
+			if !output.Hit {
+				return -1
+			}
+			got = output.Fraction
+			return output.Fraction
+		})
+		if got != want {
+			t.Fatalf("step %d: got closest hit %v, want %v", step, got, want)
+		}
+	}
+	if balance := tree.MaxBalance(); balance > 1 {
+		t.Errorf("got balance %d, want at most 1", balance)
+	}
+
+	tree.RebuildBottomUp()
+	if err := tree.Validate(); err != nil {
+		t.Fatalf("after rebuilding: %v", err)
+	}
+	tree.ShiftOrigin(Point{10, 20})
+	if err := tree.Validate(); err != nil {
+		t.Fatalf("after shifting the origin: %v", err)
+	}
+	for id, aabb := range boxes {
+		shifted := AABB{aabb.Min.Sub(Point{10, 20}), aabb.Max.Sub(Point{10, 20})}
+		if !tree.FatAABB(id).Contains(shifted) {
+			t.Fatalf("shifted box %v does not contain %v", tree.FatAABB(id), shifted)
+		}
+	}
+}
+
+func TestDynamicTreeMoved(t *testing.T) {
+	tree := NewDynamicTree(nil)
+	aabb := AABB{Point{0, 0}, Point{1, 1}}
+	id := tree.CreateProxy(aabb, nil)
+	if !tree.Moved(id) {
+		t.Error("new proxy is not moved")
+	}
+	tree.ClearMoved(id)
+
+	// Small moves stay within the enlarged box
+	d := Point{0.01, 0}
+	if tree.MoveProxy(id, AABB{aabb.Min.Add(d), aabb.Max.Add(d)}, d) || tree.Moved(id) {
+		t.Error("small move updated the tree")
+	}
+	d = Point{5, 0}
+	if !tree.MoveProxy(id, AABB{aabb.Min.Add(d), aabb.Max.Add(d)}, d) || !tree.Moved(id) {
+		t.Error("large move did not update the tree")
+	}
+
+	// The enlarged box extends in the direction of the displacement
+	settings := MeterSettings()
+	want := AABB{aabb.Min.Add(d), aabb.Max.Add(d).Add(d.Mul(settings.AABBMultiplier))}.Expand(settings.AABBMargin)
+	if got := tree.FatAABB(id); !approxEqualPoint(got.Min, want.Min) || !approxEqualPoint(got.Max, want.Max) {
+		t.Errorf("got enlarged box %v, want %v", got, want)
+	}
+}
+
+func TestDynamicTreeInvalidProxy(t *testing.T) {
+	tree := NewDynamicTree(nil)
+	tree.CreateProxy(AABB{Point{0, 0}, Point{1, 1}}, nil)
+	tree.CreateProxy(AABB{Point{5, 0}, Point{6, 1}}, nil)
+
+	// The root is the parent of the proxies
+	parent := tree.root
+
+	tests := []struct {
+		name string
+		fn   func(id int)
+	}{
+		{"DestroyProxy", func(id int) { tree.DestroyProxy(id) }},
+		{"MoveProxy", func(id int) { tree.MoveProxy(id, AABB{}, Point{}) }},
+		{"UserData", func(id int) { tree.UserData(id) }},
+		{"FatAABB", func(id int) { tree.FatAABB(id) }},
+		{"Moved", func(id int) { tree.Moved(id) }},
+		{"ClearMoved", func(id int) { tree.ClearMoved(id) }},
+	}
+	for _, test := range tests {
+		for _, id := range []int{-1, parent, len(tree.nodes)} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%s: no panic for id %d", test.name, id)
+					}
+				}()
+				test.fn(id)
+			}()
+		}
+	}
+}